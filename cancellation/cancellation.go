@@ -0,0 +1,127 @@
+package cancellation
+
+import (
+	"github.com/SnowRipple/crane/exit"
+	log "github.com/SnowRipple/crane/logger"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+)
+
+var logger = log.GetLogger()
+
+//State of the current crane invocation.Once cancelled (Ctrl-C, SIGTERM or the --timeout expired)
+//running commands are terminated and no new ones are started.
+var (
+	mutex   sync.Mutex
+	done    = make(chan struct{})
+	reason  error
+	signals = make(chan os.Signal, 1)
+)
+
+//Starts listening for SIGINT/SIGTERM and cancels the invocation once the timeout expires (0 means no timeout).
+//The second signal ends crane immediately without any cleanup.
+func Start(timeout time.Duration) {
+
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+
+	go func() {
+		received := <-signals
+		logger.Warning("Received %v, stopping...(press Ctrl-C again to exit immediately)", received)
+		Cancel(exit.Errorf(exit.INTERRUPTED, "Interrupted by %v", received))
+
+		received = <-signals
+		logger.Error("Received %v again, exiting without cleanup.", received)
+		os.Exit(exit.INTERRUPTED)
+	}()
+
+	if timeout > 0 {
+		time.AfterFunc(timeout, func() {
+			Cancel(exit.Errorf(exit.TIMEOUT, "Crane did not finish within %v", timeout))
+		})
+	}
+}
+
+//Stops listening for signals.
+func Stop() {
+	signal.Stop(signals)
+}
+
+//Cancels the invocation with a given reason.Only the first reason is kept.
+func Cancel(err error) {
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	if reason != nil {
+		return
+	}
+
+	logger.Debug("Cancelling: %v", err)
+	reason = err
+	close(done)
+}
+
+//Returns a channel which is closed when the invocation is cancelled.
+func Done() <-chan struct{} {
+	return done
+}
+
+//Returns the reason of the cancellation or nil if the invocation was not cancelled.
+func Err() error {
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	return reason
+}
+
+//Returns a channel which is closed when the invocation is cancelled or a given timeout (0 means no timeout) expires.
+//The returned function must be called exactly once when waiting is over, its error tells why the channel was closed.
+func WithTimeout(timeout time.Duration) (<-chan struct{}, func() error) {
+
+	if timeout <= 0 {
+		return done, Err
+	}
+
+	var (
+		expiredMutex sync.Mutex
+		expired      bool
+		stop         = make(chan struct{})
+		finished     = make(chan struct{})
+	)
+
+	go func() {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+
+		select {
+		case <-done:
+		case <-timer.C:
+			expiredMutex.Lock()
+			expired = true
+			expiredMutex.Unlock()
+		case <-stop:
+			return
+		}
+		close(finished)
+	}()
+
+	return finished, func() error {
+		select {
+		case <-finished:
+		default:
+			close(stop)
+		}
+
+		expiredMutex.Lock()
+		defer expiredMutex.Unlock()
+
+		if expired {
+			return exit.Errorf(exit.TIMEOUT, "Command did not finish within %v", timeout)
+		}
+		return Err()
+	}
+}
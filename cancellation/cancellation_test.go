@@ -0,0 +1,31 @@
+package cancellation
+
+import (
+	"github.com/SnowRipple/crane/exit"
+	"testing"
+	"time"
+)
+
+func TestWithTimeout_expired(t *testing.T) {
+
+	expired, stop := WithTimeout(10 * time.Millisecond)
+
+	select {
+	case <-expired:
+	case <-time.After(time.Second):
+		t.Fatalf("Timeout did not expire")
+	}
+
+	if code := exit.Code(stop()); code != exit.TIMEOUT {
+		t.Errorf("Expected exit code %d, got %d", exit.TIMEOUT, code)
+	}
+}
+
+func TestWithTimeout_finishedInTime(t *testing.T) {
+
+	_, stop := WithTimeout(time.Hour)
+
+	if err := stop(); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+}
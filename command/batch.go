@@ -0,0 +1,158 @@
+package command
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/SnowRipple/crane/cancellation"
+	"github.com/SnowRipple/crane/constants"
+	"github.com/SnowRipple/crane/container"
+	"github.com/SnowRipple/crane/exit"
+	ownLog "github.com/SnowRipple/crane/logger"
+	"github.com/SnowRipple/crane/output"
+	"github.com/mitchellh/cli"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+/*
+ Outcomes of an operation on a single container
+*/
+const (
+	RESULT_OK      = "ok"
+	RESULT_FAILED  = "failed"
+	RESULT_SKIPPED = "skipped"
+)
+
+//Outcome of an operation on a single container.
+type batchResult struct {
+	containerName string
+	outcome       string
+	err           error
+}
+
+//Runs an operation on multiple containers following the chosen failure policy:
+//->fail fast (default) - containers after the first failing one are skipped.
+//->keep going - every container is processed no matter how many failed.
+type batch struct {
+	ui        cli.Ui
+	keepGoing bool
+	failed    bool
+	results   []batchResult
+}
+
+//Creates a new batch using the failure policy chosen with --keep-going or --fail-fast.
+func newBatch(ui cli.Ui, options constants.CommonFlags) (*batch, error) {
+
+	if options.KeepGoing && options.FailFast {
+		return nil, exit.Errorf(exit.USAGE_ERROR, "Can't use \"--keep-going\" and \"--fail-fast\" at the same time.Please choose one.")
+	}
+
+	return &batch{ui: ui, keepGoing: options.KeepGoing}, nil
+}
+
+//Runs the operation on a container unless an earlier container failed in the fail fast mode.
+func (b *batch) Run(containerName string, operation func() error) {
+
+	if cancellation.Err() != nil {
+		logger.Debug("Skipping container %q since crane was cancelled.", containerName)
+		b.results = append(b.results, batchResult{containerName: containerName, outcome: RESULT_SKIPPED})
+		return
+	}
+
+	if b.failed && !b.keepGoing {
+		logger.Debug("Skipping container %q since an earlier container failed.", containerName)
+		b.results = append(b.results, batchResult{containerName: containerName, outcome: RESULT_SKIPPED})
+		return
+	}
+
+	defer ownLog.ScopeField(ownLog.CONTAINER_FIELD, containerName)()
+
+	if err := operation(); err != nil {
+		logger.Error("Container %q failed: %v", containerName, err)
+		output.FailPending(exit.Code(err), err.Error())
+
+		b.failed = true
+		b.results = append(b.results, batchResult{containerName: containerName, outcome: RESULT_FAILED, err: err})
+		return
+	}
+
+	b.results = append(b.results, batchResult{containerName: containerName, outcome: RESULT_OK})
+}
+
+//Prints the summary of all containers and returns the exit code of the first failure (0 if all succeeded).
+func (b *batch) Finish() int {
+
+	if len(b.results) > 1 && !output.IsMachineReadable() {
+		b.printSummary()
+	}
+
+	var (
+		firstError error
+		failures   int
+	)
+
+	for _, result := range b.results {
+		if result.err != nil {
+			if firstError == nil {
+				firstError = result.err
+			}
+			failures++
+		}
+	}
+
+	if firstError == nil {
+		return 0
+	}
+
+	output.SetError(fmt.Sprintf("%d of %d containers failed, first error: %v", failures, len(b.results), firstError))
+	return exit.Code(firstError)
+}
+
+//Prints the outcome of every container as an aligned table.
+func (b *batch) printSummary() {
+
+	var table bytes.Buffer
+
+	writer := tabwriter.NewWriter(&table, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(writer, "CONTAINER\tRESULT\tERROR")
+	for _, result := range b.results {
+		errorMessage := ""
+		if result.err != nil {
+			errorMessage = ownLog.Redact(singleLine(result.err.Error()))
+		}
+		fmt.Fprintf(writer, "%s\t%s\t%s\n", result.containerName, result.outcome, errorMessage)
+	}
+	writer.Flush()
+
+	b.ui.Output(table.String())
+}
+
+//Returns names of containers in alphabetical order so multi-container commands process them predictably.
+func sortedContainerNames(containers map[string]container.Container) []string {
+
+	var containerNames []string
+	for containerName, _ := range containers {
+		containerNames = append(containerNames, containerName)
+	}
+	sort.Strings(containerNames)
+
+	return containerNames
+}
+
+//Returns names of containers recorded in the state file in alphabetical order.
+func sortedStateContainerNames(stateContainers map[string]container.StateContainer) []string {
+
+	var containerNames []string
+	for containerName, _ := range stateContainers {
+		containerNames = append(containerNames, containerName)
+	}
+	sort.Strings(containerNames)
+
+	return containerNames
+}
+
+//Squeezes a multi-line message (e.g. docker error output) into a single line so it fits into the table.
+func singleLine(message string) string {
+	return strings.Join(strings.Fields(message), " ")
+}
@@ -6,15 +6,26 @@ import (
 	"github.com/SnowRipple/crane/command/executer"
 	"github.com/SnowRipple/crane/constants"
 	"github.com/SnowRipple/crane/container"
+	"github.com/SnowRipple/crane/exit"
+	ownLog "github.com/SnowRipple/crane/logger"
+	"github.com/SnowRipple/crane/output"
 	"github.com/SnowRipple/crane/utils"
 	flags "github.com/jessevdk/go-flags"
 	"github.com/mitchellh/cli"
 	"strings"
 )
 
+const (
+	BUILD_SUCCESS_PREFIX = "Successfully built " //Line printed by docker build once the image is ready
+	IMAGE_TYPE_OPTION    = "--type=image"
+	CONTEXT_HASH_FORMAT  = "{{index .Config.Labels \"" + container.CONTEXT_HASH_LABEL + "\"}}"
+	IMAGE_ID_FORMAT      = "{{.Id}}" //Keeps the output of inspect short
+)
+
 // BuildImageCommand builds an image using Dockerfile providedin the Cranefile.toml
 type BuildImageCommand struct {
 	Ui         cli.Ui
+	Hosts      map[string]container.Host
 	Containers map[string]container.Container
 }
 
@@ -43,7 +54,7 @@ func (c *BuildImageCommand) Run(containers []string) int {
 	containers, err := flags.ParseArgs(&options, containers)
 
 	if err != nil {
-		logger.Fatalf("Failed to parse options of the build command due to error: %v", err)
+		return exitWithError(exit.Errorf(exit.USAGE_ERROR, "Failed to parse options of the build command due to error: %v", err))
 	}
 
 	if options.All { //Build all images
@@ -55,10 +66,22 @@ func (c *BuildImageCommand) Run(containers []string) int {
 
 	var images []string
 
+	defer ownLog.RemoveField(ownLog.CONTAINER_FIELD) //The last container must not stick to later records
 	for _, chosenContainerName := range containers {
-		chosenContainerConfig := utils.GetRequestedContainerConfig(c.Containers, chosenContainerName, true) //throw an error if container is not found
+		ownLog.SetField(ownLog.CONTAINER_FIELD, chosenContainerName)
+		chosenContainerConfig, err := utils.GetRequestedContainerConfig(c.Containers, chosenContainerName, true) //throw an error if container is not found
+		if err != nil {
+			return exitWithError(err)
+		}
+
+		host, err := utils.GetRequestedHost(c.Hosts, chosenContainerConfig.Host)
+		if err != nil {
+			return exitWithError(err)
+		}
 
-		c.buildImage(chosenContainerConfig.Dockerfile, chosenContainerConfig.Image)
+		if err := c.buildImage(host, chosenContainerName, chosenContainerConfig); err != nil {
+			return exitWithError(err)
+		}
 		images = append(images, chosenContainerConfig.Image)
 	}
 
@@ -66,90 +89,146 @@ func (c *BuildImageCommand) Run(containers []string) int {
 	return 0
 }
 
-//Checks if an image needs to be build from Dockerfile.If yes then the image will be build.
-func (c *BuildImageCommand) BuildImageIfNeeded(container container.Container) {
-	if isDockerfileBuildNeeded(container.Image) {
-		c.buildImage(container.Dockerfile, container.Image)
+//Makes sure the image of a container is on the host the container runs on according to the container's pull policy.
+//The public search index is never consulted.In the offline mode images are never pulled.
+func (c *BuildImageCommand) PrepareImage(host container.Host, containerName string, containerConfig container.Container) error {
+
+	policy := containerConfig.PullPolicy
+	if executer.Offline() && (policy == container.PULL_ALWAYS || policy == container.PULL_IF_MISSING) {
+		logger.Debug("Offline mode: image %q is not pulled, pull policy %q acts like %q", containerConfig.Image, policy, container.PULL_NEVER)
+		policy = container.PULL_NEVER
 	}
 
-}
+	switch policy {
+	case container.PULL_BUILD:
+		buildNeeded, err := isSourcesBuildNeeded(host, containerName, containerConfig)
+		if err != nil || !buildNeeded {
+			return err
+		}
+		return c.buildImage(host, containerName, containerConfig)
 
-//Builds a docker image based on Dockerfile provided in the Cranefile.
-func (c *BuildImageCommand) buildImage(dockerfilePath, imageName string) {
+	case container.PULL_ALWAYS:
+		return pullImage(host, containerName, containerConfig.Image)
+	}
 
-	logger.Debug("Building image from the Dockerfile...")
+	exists, err := checkIfImageExists(host, containerConfig.Image)
+	if err != nil || exists {
+		return err
+	}
+	if policy == container.PULL_NEVER {
+		return exit.Errorf(exit.CONFIG_ERROR, "Image %q of container %q does not exist on the host and can't be pulled (PULL_POLICY %q or offline mode).Please pull or build it first.", containerConfig.Image, containerName, container.PULL_NEVER)
+	}
+	return pullImage(host, containerName, containerConfig.Image)
+}
 
-	//"-t" means build with specified name
-	buildCommand := []string{constants.DOCKER, constants.BUILD, "-t", imageName, dockerfilePath}
+//Pulls an image onto the host showing the progress.
+func pullImage(host container.Host, containerName, imageName string) error {
 
-	buildBytes, err := executer.GetCommandOutput(buildCommand)
+	action := output.StartAction(output.PULL_ACTION, containerName)
+	action.Image = imageName
 
-	buildResults := utils.ExtractContainerMessage(buildBytes, err)
-	logger.Debug("The building process results:\n%s", buildResults)
+	logger.Notice("Pulling image %q...", imageName)
+	outputBytes, err := executer.GetStreamedCommandOutput(host.DockerCommand(constants.PULL, imageName), output.Stdout())
 	if err != nil {
-		logger.Fatal("Error when trying to build image %q using the Dockerfile located in %q:\n%q", imageName, dockerfilePath, buildResults)
+		return exit.Errorf(exit.DOCKER_ERROR, "Failed to pull image %q:%s", imageName, utils.ExtractContainerMessage(nil, err))
 	}
+	logger.Debug("Pulled image %q:\n%s", imageName, outputBytes)
+
+	action.Finish()
+	return nil
 }
 
-//Checks if the Dockerfile build is needed.
-//1. Check if image exists in the host system.If yes, then Dockerfile build is not needed.
-//2. Check if image exists in the docker public repository.If yes, then Dockerfile build is not needed.
-//3.If 1. and 2. are false then the Dockerfile build is needed.
-func isDockerfileBuildNeeded(imageName string) bool {
+//Checks if an image has to be built from its sources: it does not exist on the host yet or its sources changed since it was built.
+//Images of the public repository are not considered, they would shadow the local build.
+func isSourcesBuildNeeded(host container.Host, containerName string, containerConfig container.Container) (bool, error) {
 
-	//Check if image is present in the host system.
+	build, err := containerConfig.ResolvedBuild()
+	if err != nil {
+		return false, exit.Errorf(exit.Code(err), "Container %q: %v", containerName, err)
+	}
+	sourcesHash, err := build.Hash()
+	if err != nil {
+		return false, err
+	}
 
-	if checkIfImageExists(imageName) || checkIfImagePresentInRepository(imageName) {
-		return false //Image exists so Dockerfile build is not needed.
+	inspectCommand := host.DockerCommand(constants.INSPECT, IMAGE_TYPE_OPTION, constants.FORMAT+CONTEXT_HASH_FORMAT, containerConfig.Image)
+	outputBytes, err := executer.GetCommandOutput(inspectCommand)
+	if utils.IsMissingObject(outputBytes, err) {
+		logger.Debug("Image %q does not exist on the host yet", containerConfig.Image)
+		return true, nil
+	} else if err != nil {
+		return false, exit.Errorf(exit.DOCKER_ERROR, "Failed to inspect image %q of container %q:%s", containerConfig.Image, containerName, utils.ExtractContainerMessage(outputBytes, err))
 	}
 
-	return true
+	builtHash := strings.TrimSpace(string(outputBytes))
+	if builtHash != sourcesHash {
+		logger.Notice("Sources of image %q changed since it was built, rebuilding it...", containerConfig.Image)
+		logger.Debug("Image %q was built from sources with hash %q, current hash is %q", containerConfig.Image, builtHash, sourcesHash)
+		return true, nil
+	}
+	logger.Debug("Image %q is up to date with its sources", containerConfig.Image)
+	return false, nil
 }
 
-//Checks if a given image is present in the docker public repository.
-func checkIfImagePresentInRepository(imageName string) bool {
+//Builds a docker image based on the build settings provided in the Cranefile.The build output is streamed as it goes.
+func (c *BuildImageCommand) buildImage(host container.Host, containerName string, containerConfig container.Container) error {
 
-	logger.Debug("Checking if the image %s is present in the docker public repository...", imageName)
+	logger.Debug("Building image from the Dockerfile...")
 
-	checkPublicRepositoryCommand := []string{constants.DOCKER, constants.SEARCH, imageName}
+	action := output.StartAction(output.BUILD_ACTION, containerName)
+	action.Image = containerConfig.Image
 
-	imageSearchBytes, err := executer.GetCommandOutput(checkPublicRepositoryCommand)
-	searchMessage := utils.ExtractContainerMessage(imageSearchBytes, err)
-	logger.Debug("Search command results:\n%s", searchMessage)
+	build, err := containerConfig.ResolvedBuild()
 	if err != nil {
-		logger.Fatal("Error when searching for the image:"+imageName+" in the docker public repository:\n%s", searchMessage)
+		return exit.Errorf(exit.Code(err), "Container %q: %v", containerName, err)
 	}
+	sourcesHash, err := build.Hash()
+	if err != nil {
+		return err
+	}
+	build = build.WithLabel(container.CONTEXT_HASH_LABEL, sourcesHash) //Lets later runs detect changed sources
+	if executer.Offline() && build.Pull {
+		logger.Notice("Offline mode: base images of %q are not pulled, the ones on the host are used.", containerConfig.Image)
+		build.Pull = false
+	}
+	buildCommand := host.DockerCommand(append([]string{constants.BUILD}, build.Options(containerConfig.Image)...)...)
 
-	if strings.Contains(searchMessage, "Found 0 results matching your query") {
-		logger.Debug("Image: %s does not exists in the public repository", imageName)
-		return false
-	} else {
-		logger.Debug("Image: %s exists in the docker public repository.", imageName)
+	logger.Notice("Building image %q from %q...", containerConfig.Image, build.Context)
+	buildBytes, err := executer.GetStreamedCommandOutput(buildCommand, output.Stdout())
+	if err != nil {
+		return exit.Errorf(exit.DOCKER_ERROR, "Error when trying to build image %q using the context %q:%s", containerConfig.Image, build.Context, utils.ExtractContainerMessage(nil, err))
 	}
-	return true
+
+	action.ImageID = extractBuiltImageId(string(buildBytes))
+	action.Finish()
+	return nil
 }
 
-//Checks if a given image exists in the host's system.
-func checkIfImageExists(imageName string) bool {
+//Extracts the id of a freshly built image from the docker build output ("Successfully built <id>").
+func extractBuiltImageId(buildOutput string) string {
 
-	logger.Debug("Checking if the image %s is present in the host system...", imageName)
+	for _, line := range strings.Split(buildOutput, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), BUILD_SUCCESS_PREFIX) {
+			return strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(line), BUILD_SUCCESS_PREFIX))
+		}
+	}
+	return ""
+}
 
-	imagesCommand := []string{constants.SUDO, constants.DOCKER, constants.IMAGES, imageName}
+//Checks if a given image exists in the host's system.Inspecting the image resolves tags and registries the way docker run does.
+func checkIfImageExists(host container.Host, imageName string) (bool, error) {
 
-	imageCommandOutputBytes, err := executer.GetCommandOutput(imagesCommand)
+	logger.Debug("Checking if the image %s is present in the host system...", imageName)
 
-	imagesOutput := utils.ExtractContainerMessage(imageCommandOutputBytes, err)
-	logger.Debug("Images command results:\n%s", imagesOutput)
-	if err != nil {
-		logger.Fatal("Error when searching for the image:"+imageName+" in the docker public repository:\n%s", imagesOutput)
-	}
-	if strings.Contains(string(imageCommandOutputBytes), imageName) {
-		logger.Debug("Image %s exists in the host system.", imageName)
-		return true
-	} else {
+	inspectBytes, err := executer.GetCommandOutput(host.DockerCommand(constants.INSPECT, IMAGE_TYPE_OPTION, constants.FORMAT+IMAGE_ID_FORMAT, imageName))
+	if utils.IsMissingObject(inspectBytes, err) {
 		logger.Debug("Image %s does NOT exist in the host system.", imageName)
+		return false, nil
+	} else if err != nil {
+		return false, exit.Errorf(exit.DOCKER_ERROR, "Error when searching for the image:%s in the host system:%s", imageName, utils.ExtractContainerMessage(inspectBytes, err))
 	}
-	return false
+	logger.Debug("Image %s exists in the host system.", imageName)
+	return true, nil
 }
 
 func (c *BuildImageCommand) Synopsis() string {
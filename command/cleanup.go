@@ -0,0 +1,77 @@
+package command
+
+import (
+	"github.com/SnowRipple/crane/command/executer"
+	"github.com/SnowRipple/crane/constants"
+	"github.com/SnowRipple/crane/container"
+	"github.com/SnowRipple/crane/io"
+	"github.com/SnowRipple/crane/utils"
+	"sort"
+	"sync"
+)
+
+//Container created during this crane invocation and the docker host it was created on.
+type createdContainer struct {
+	id   string
+	host container.Host
+}
+
+//Containers created during this crane invocation (container name -> created container).
+//When crane is interrupted or times out they are removed so nothing is left running.
+var (
+	createdContainersMutex sync.Mutex
+	createdContainers      = map[string]createdContainer{}
+)
+
+//Remembers a container created during this invocation.
+func trackCreatedContainer(containerName, containerId string, host container.Host) {
+
+	createdContainersMutex.Lock()
+	defer createdContainersMutex.Unlock()
+
+	createdContainers[containerName] = createdContainer{id: containerId, host: host}
+}
+
+//Forgets a container that has to keep running even if crane is cancelled later.
+func untrackCreatedContainer(containerName string) {
+
+	createdContainersMutex.Lock()
+	defer createdContainersMutex.Unlock()
+
+	delete(createdContainers, containerName)
+}
+
+//Removes containers created during this invocation and drops them from the state file.
+//Used after crane was cancelled so the state file only lists containers that still exist.
+func RemoveCreatedContainers() error {
+
+	createdContainersMutex.Lock()
+	defer createdContainersMutex.Unlock()
+
+	var (
+		containerNames []string
+		removedNames   []string
+	)
+	for containerName, _ := range createdContainers {
+		containerNames = append(containerNames, containerName)
+	}
+	sort.Strings(containerNames)
+
+	for _, containerName := range containerNames {
+		containerId := createdContainers[containerName].id
+		logger.Notice("Removing container %q created before crane was cancelled...", containerName)
+
+		outputBytes, err := executer.GetCleanupCommandOutput(createdContainers[containerName].host.DockerCommand(constants.REMOVE, constants.FORCE, containerId))
+		if err != nil {
+			logger.Error("Failed to remove container %q with id %q:%s", containerName, containerId, utils.ExtractContainerMessage(outputBytes, err))
+			continue
+		}
+		removedNames = append(removedNames, containerName)
+		delete(createdContainers, containerName)
+	}
+
+	if len(removedNames) == 0 {
+		return nil
+	}
+	return io.RemoveStateContainers(removedNames)
+}
@@ -0,0 +1,158 @@
+package command
+
+import (
+	"flag"
+	"github.com/SnowRipple/crane/command/executer"
+	"github.com/SnowRipple/crane/config"
+	"github.com/SnowRipple/crane/constants"
+	"github.com/SnowRipple/crane/container"
+	"github.com/SnowRipple/crane/exit"
+	ownLog "github.com/SnowRipple/crane/logger"
+	"github.com/SnowRipple/crane/ssh"
+	"github.com/SnowRipple/crane/utils"
+	"github.com/mitchellh/cli"
+	"strings"
+)
+
+const COPY_ARGUMENT_COUNT = 2
+
+// CopyCommand copies files and directories between the host and containers.
+type CopyCommand struct {
+	Ui     cli.Ui
+	Config config.TomlConfig
+}
+
+func (c *CopyCommand) Help() string {
+	helpText := `
+  Usage: crane cp <containerName>:<containerPath> <hostPath>
+
+  Copies a file or a directory (recursively) from a container to the host.
+
+  Usage: crane cp <hostPath> <containerName>:<containerPath>
+
+  Copies a file or a directory (recursively) from the host to a container.
+
+  Daemonized containers are accessed over sftp so they have to be started first.
+  Other containers are accessed using docker's copy, they must have been run by crane at least once.
+  `
+	return strings.TrimSpace(helpText)
+}
+
+//Copy files between the host and a container.
+func (c *CopyCommand) Run(arguments []string) int {
+
+	logger.Debug("Entered cp command...")
+
+	cmdFlags := flag.NewFlagSet("cp", flag.ContinueOnError)
+	cmdFlags.Usage = func() { c.Ui.Output(c.Help()) }
+
+	if len(arguments) != COPY_ARGUMENT_COUNT {
+		return exitWithError(exit.Errorf(exit.USAGE_ERROR, "Wrong amount of arguments provided for the cp command: Expected %d, Actual %d.Please correct.", COPY_ARGUMENT_COUNT, len(arguments)))
+	}
+
+	source, destination := arguments[0], arguments[1]
+
+	var err error
+
+	if containerName, containerPath, ok := c.extractContainerPath(source); ok { //container -> host
+		err = c.copyFromContainer(containerName, containerPath, destination)
+	} else if containerName, containerPath, ok := c.extractContainerPath(destination); ok { //host -> container
+		err = c.copyToContainer(source, containerName, containerPath)
+	} else {
+		err = exit.Errorf(exit.USAGE_ERROR, "Neither of the cp arguments refers to a container defined in the Cranefile.Please use <containerName>:<containerPath> format.")
+	}
+
+	if err != nil {
+		return exitWithError(err)
+	}
+	return 0
+}
+
+//Splits the <containerName>:<containerPath> argument. Returns false if the argument does not refer to a Cranefile container.
+func (c *CopyCommand) extractContainerPath(argument string) (containerName, containerPath string, ok bool) {
+
+	parts := strings.SplitN(argument, constants.COMMANDS_DELIMITER, 2)
+	if len(parts) != 2 {
+		return "", "", false
+	}
+
+	if _, exists := c.Config.CraneConfig.Containers[parts[0]]; !exists {
+		return "", "", false
+	}
+
+	return parts[0], parts[1], true
+}
+
+//Copies a file or a directory from a container to the host.
+func (c *CopyCommand) copyFromContainer(containerName, containerPath, hostPath string) error {
+
+	defer ownLog.ScopeField(ownLog.CONTAINER_FIELD, containerName)()
+
+	containerConfig, containerState, err := utils.GetContainerConfigAndState(c.Config, containerName, true, true) //Container must have been created by crane
+	if err != nil {
+		return err
+	}
+	host, err := utils.GetRequestedHost(c.Config.CraneConfig.Hosts, containerState.Host)
+	if err != nil {
+		return err
+	}
+
+	logger.Notice("Copying %q from container %q into %q...", containerPath, containerName, hostPath)
+
+	if containerConfig.Daemonized {
+		err = ssh.SftpDownload(containerState.SshAddress(host), containerConfig.Username, containerConfig.Password, containerPath, hostPath)
+	} else {
+		err = dockerCopy(host, containerState.ID+constants.COMMANDS_DELIMITER+containerPath, hostPath)
+	}
+	if err != nil {
+		return err
+	}
+
+	logger.Notice("Successfully copied %q from container %q into %q", containerPath, containerName, hostPath)
+	return nil
+}
+
+//Copies a file or a directory from the host to a container.
+func (c *CopyCommand) copyToContainer(hostPath, containerName, containerPath string) error {
+
+	defer ownLog.ScopeField(ownLog.CONTAINER_FIELD, containerName)()
+
+	containerConfig, containerState, err := utils.GetContainerConfigAndState(c.Config, containerName, true, true) //Container must have been created by crane
+	if err != nil {
+		return err
+	}
+	host, err := utils.GetRequestedHost(c.Config.CraneConfig.Hosts, containerState.Host)
+	if err != nil {
+		return err
+	}
+
+	logger.Notice("Copying %q into %q in container %q...", hostPath, containerPath, containerName)
+
+	if containerConfig.Daemonized {
+		err = ssh.SftpUpload(containerState.SshAddress(host), containerConfig.Username, containerConfig.Password, hostPath, containerPath)
+	} else {
+		err = dockerCopy(host, hostPath, containerState.ID+constants.COMMANDS_DELIMITER+containerPath)
+	}
+	if err != nil {
+		return err
+	}
+
+	logger.Notice("Successfully copied %q into %q in container %q", hostPath, containerPath, containerName)
+	return nil
+}
+
+//Copies files using docker's copy.Directories are copied recursively.
+func dockerCopy(host container.Host, source, destination string) error {
+
+	dockerCommand := host.DockerCommand(constants.COPY, source, destination)
+
+	outputBytes, err := executer.GetCommandOutput(dockerCommand)
+	if err != nil {
+		return exit.Errorf(exit.DOCKER_ERROR, "Error during \"cp\" command:%s", utils.ExtractContainerMessage(outputBytes, err))
+	}
+	return nil
+}
+
+func (c *CopyCommand) Synopsis() string {
+	return "Copy files between the host and containers."
+}
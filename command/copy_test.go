@@ -0,0 +1,38 @@
+package command
+
+import (
+	"github.com/SnowRipple/crane/config"
+	"github.com/SnowRipple/crane/container"
+	"github.com/mitchellh/cli"
+	"testing"
+)
+
+func TestCopyCommand_implements(t *testing.T) {
+	var _ cli.Command = &CopyCommand{}
+}
+
+func TestCopyCommand_extractContainerPath(t *testing.T) {
+
+	var copyCommand = &CopyCommand{}
+	copyCommand.Config = config.TomlConfig{}
+	copyCommand.Config.CraneConfig.Containers = map[string]container.Container{"first": {}}
+
+	cases := []struct {
+		argument      string
+		containerName string
+		containerPath string
+		ok            bool
+	}{
+		{"first:/etc/hosts", "first", "/etc/hosts", true},
+		{"first:/tmp/a:b", "first", "/tmp/a:b", true},
+		{"second:/etc/hosts", "", "", false},
+		{"/home/foo/file", "", "", false},
+	}
+
+	for _, testCase := range cases {
+		containerName, containerPath, ok := copyCommand.extractContainerPath(testCase.argument)
+		if containerName != testCase.containerName || containerPath != testCase.containerPath || ok != testCase.ok {
+			t.Errorf("extractContainerPath(%q) = %q, %q, %t; expected %q, %q, %t", testCase.argument, containerName, containerPath, ok, testCase.containerName, testCase.containerPath, testCase.ok)
+		}
+	}
+}
@@ -25,7 +25,9 @@ func (c *CreateCommand) Run(_ []string) int {
 	cmdFlags := flag.NewFlagSet("create", flag.ContinueOnError)
 	cmdFlags.Usage = func() { c.Ui.Output(c.Help()) }
 
-	io.Create()
+	if err := io.Create(); err != nil {
+		return exitWithError(err)
+	}
 	return 0
 }
 
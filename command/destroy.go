@@ -5,8 +5,12 @@ import (
 	"github.com/SnowRipple/crane/command/executer"
 	"github.com/SnowRipple/crane/config"
 	"github.com/SnowRipple/crane/constants"
+	"github.com/SnowRipple/crane/container"
+	"github.com/SnowRipple/crane/exit"
 	"github.com/SnowRipple/crane/io"
+	"github.com/SnowRipple/crane/output"
 	"github.com/SnowRipple/crane/utils"
+	flags "github.com/jessevdk/go-flags"
 	"github.com/mitchellh/cli"
 	"strings"
 )
@@ -25,16 +29,23 @@ func (c *DestroyCommand) Help() string {
   
   Usage: crane destroy <containerName1> <containerName2>
   
-  Kills and removes all specified containers.`
+  Kills and removes all specified containers.
+
+  Options:
+
+  -k(--keep-going) : Carries on destroying remaining containers when one of them fails.
+  --fail-fast : Stops at the first container that failed to be destroyed (default).
+  --volumes : Also removes named volumes crane created for destroyed containers.`
 	return strings.TrimSpace(helpText)
 }
 
-//Pulls all images defined in the Cranefile
+//Kills and removes containers created by the crane.
 func (c *DestroyCommand) Run(arguments []string) int {
 
 	var (
-		containersIdsToBeDestroyed   []string
+		options                      constants.CommonFlags
 		containersNamesToBeDestroyed []string
+		destroyedContainersNames     []string
 	)
 
 	logger.Debug("Entered destroy command...")
@@ -42,8 +53,11 @@ func (c *DestroyCommand) Run(arguments []string) int {
 	cmdFlags := flag.NewFlagSet("destroy", flag.ContinueOnError)
 	cmdFlags.Usage = func() { c.Ui.Output(c.Help()) }
 
-	dockerKillCommand := []string{constants.DOCKER, constants.KILL}
-	dockerRemoveCommand := []string{constants.DOCKER, constants.REMOVE}
+	arguments, err := flags.ParseArgs(&options, arguments)
+	if err != nil {
+		return exitWithError(exit.Errorf(exit.USAGE_ERROR, "Failed to parse destroy flags for following CLI arguments:\n%v", arguments))
+	}
+
 	killThemAll := true //Kill all containers
 
 	if len(arguments) > 0 { //kill only specified containers
@@ -54,76 +68,87 @@ func (c *DestroyCommand) Run(arguments []string) int {
 	stateContainers := c.Config.CraneState.StateContainers
 
 	if len(stateContainers) == 0 {
-		logger.Fatalf("There are no containers in the state file hence no containers will be destroyed.You can destroy only containers that were created by the crane.")
+		return exitWithError(exit.Errorf(exit.CONFIG_ERROR, "There are no containers in the state file hence no containers will be destroyed.You can destroy only containers that were created by the crane."))
 	}
 
-	//append containers ids to the docker kill command
-	for containerName, stateContainer := range stateContainers {
-		if killThemAll { //Kill all
-			containersIdsToBeDestroyed, containersNamesToBeDestroyed = addToBeDestroyedList(containerName, stateContainer.ID, containersIdsToBeDestroyed, containersNamesToBeDestroyed)
-		} else { //Kill specific containers only
-			for _, containerToBeDeleted := range arguments {
-				if containerToBeDeleted == containerName {
-					containersIdsToBeDestroyed, containersNamesToBeDestroyed = addToBeDestroyedList(containerName, stateContainer.ID, containersIdsToBeDestroyed, containersNamesToBeDestroyed)
-					break
-				}
-			}
+	for _, containerName := range sortedStateContainerNames(stateContainers) {
+		if killThemAll || isThisContainerChosen(containerName, arguments) {
+			logger.Debug("Following container will be destroyed: %q with ID %q", containerName, stateContainers[containerName].ID)
+			containersNamesToBeDestroyed = append(containersNamesToBeDestroyed, containerName)
 		}
 	}
 
-	dockerKillCommand = append(dockerKillCommand, containersIdsToBeDestroyed...)
-	dockerRemoveCommand = append(dockerRemoveCommand, containersIdsToBeDestroyed...)
-
-	if len(containersIdsToBeDestroyed) == 0 {
-		logger.Fatal("No such containers were found in the state file  hence they cannott be deleted.Please note that you can destroy only containers created by the crane.")
+	if len(containersNamesToBeDestroyed) == 0 {
+		return exitWithError(exit.Errorf(exit.CONFIG_ERROR, "No such containers were found in the state file  hence they cannott be deleted.Please note that you can destroy only containers created by the crane."))
 	}
 
 	logger.Debug("Following containers will be destroyed:\n%v", containersNamesToBeDestroyed)
 
-	//First Kill, then Remove
-	killContainers(dockerKillCommand)
-	removeContainers(dockerRemoveCommand)
-
-	//Remove destroyed containers from the state file.
-	io.RemoveStateContainers(containersNamesToBeDestroyed)
-
-	return 0
-}
+	containersBatch, err := newBatch(c.Ui, options)
+	if err != nil {
+		return exitWithError(err)
+	}
 
-//Add container to the lists of containers to be killed and removed
-func addToBeDestroyedList(containerName, containerId string, containersIdsToBeDestroyed, containersNamesToBeDestroyed []string) ([]string, []string) {
+	for _, containerName := range containersNamesToBeDestroyed {
+		containerId := stateContainers[containerName].ID
 
-	logger.Debug("Following container will be destroyed: %q with ID %q", containerName, containerId)
-	containersIdsToBeDestroyed = append(containersIdsToBeDestroyed, containerId)
-	containersNamesToBeDestroyed = append(containersNamesToBeDestroyed, containerName)
+		containersBatch.Run(containerName, func() error {
+			host, err := utils.GetRequestedHost(c.Config.CraneConfig.Hosts, stateContainers[containerName].Host)
+			if err != nil {
+				return err
+			}
+			if err := destroyContainer(host, containerName, containerId); err != nil {
+				return err
+			}
+			if containerConfig, exists := c.Config.CraneConfig.Containers[containerName]; options.Volumes && exists {
+				removeNamedVolumes(host, containerName, containerConfig)
+			}
+			destroyedContainersNames = append(destroyedContainersNames, containerName)
+			return nil
+		})
+	}
 
-	return containersIdsToBeDestroyed, containersNamesToBeDestroyed
+	//Remove destroyed containers from the state file, failed ones stay there so they can be destroyed later.
+	if len(destroyedContainersNames) > 0 {
+		if err := io.RemoveStateContainers(destroyedContainersNames); err != nil {
+			containersBatch.Finish()
+			return exitWithError(err)
+		}
+	}
 
+	return containersBatch.Finish()
 }
 
-//Kill running containers. If containers are not running nothing will happen.
-func killContainers(killCommand []string) {
+//Kills and removes a single container.
+func destroyContainer(host container.Host, containerName, containerId string) error {
 
-	killedContainersBytes, err := executer.GetCommandOutput(killCommand)
-	if err != nil {
-		logger.Fatal("Error when trying to destroy container(s):", utils.ExtractContainerMessage(killedContainersBytes, err))
-	}
+	action := output.StartAction(output.DESTROY_ACTION, containerName)
+	action.ContainerID = containerId
 
-	killedContainers := strings.TrimSpace(string(killedContainersBytes))
-	logger.Notice("Kill command output:\n%v", killedContainers)
+	//Forced removal kills running containers and removes stopped, exited or crashed ones alike
+	if err := removeContainers(host.DockerCommand(constants.REMOVE, constants.FORCE, containerId)); err != nil {
+		return err
+	}
 
+	action.Finish()
+	return nil
 }
 
-//Remove containers from the system
-func removeContainers(removeCommand []string) {
+//Remove containers from the system. Containers that are gone already count as removed.
+func removeContainers(removeCommand []string) error {
 
 	removedContainersBytes, err := executer.GetCommandOutput(removeCommand)
+	if utils.IsMissingObject(removedContainersBytes, err) {
+		logger.Notice("Container is gone already:%s", utils.ExtractContainerMessage(removedContainersBytes, err))
+		return nil
+	}
 	if err != nil {
-		logger.Fatal("Error when trying to destroy container(s):", utils.ExtractContainerMessage(removedContainersBytes, err))
+		return exit.Errorf(exit.DOCKER_ERROR, "Error when trying to destroy container(s):%s", utils.ExtractContainerMessage(removedContainersBytes, err))
 	}
 
 	removedContainers := strings.TrimSpace(string(removedContainersBytes))
 	logger.Notice("Remove command output:\n%v", removedContainers)
+	return nil
 }
 
 func (c *DestroyCommand) Synopsis() string {
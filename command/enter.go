@@ -6,7 +6,9 @@ import (
 	"github.com/SnowRipple/crane/config"
 	"github.com/SnowRipple/crane/constants"
 	"github.com/SnowRipple/crane/container"
+	"github.com/SnowRipple/crane/exit"
 	"github.com/SnowRipple/crane/io"
+	ownLog "github.com/SnowRipple/crane/logger"
 	"github.com/SnowRipple/crane/ssh"
 	"github.com/SnowRipple/crane/utils"
 	flags "github.com/jessevdk/go-flags"
@@ -19,10 +21,6 @@ type EnterCommand struct {
 	Config config.TomlConfig
 }
 
-type enterOptions struct {
-	ForceImage bool `short:"f" long:"force" description:"If chosen, crane will assume that the chosen image already exists in the host system(useful for offline mode)" `
-}
-
 func (c *EnterCommand) Help() string {
 	helpText := `
 
@@ -45,55 +43,100 @@ func (c *EnterCommand) Run(arguments []string) int {
 	cmdFlags := flag.NewFlagSet("enter", flag.ContinueOnError)
 	cmdFlags.Usage = func() { c.Ui.Output(c.Help()) }
 
-	var options enterOptions
+	var options constants.CommonFlags
 
 	arguments, err := flags.ParseArgs(&options, arguments)
 	if err != nil {
 		logger.Debug("Failed to extract start command flags for following arguments:\n%v", arguments)
 	}
 
-	checkArgumentsValidity(arguments)
+	if err := checkArgumentsValidity(arguments); err != nil {
+		return exitWithError(err)
+	}
 
 	requestedContainerName := arguments[0]
+	defer ownLog.ScopeField(ownLog.CONTAINER_FIELD, requestedContainerName)()
+
+	if err := c.enter(requestedContainerName, options); err != nil {
+		return exitWithError(err)
+	}
+	return 0
+}
+
+//Presents the user with an interactive shell inside a container.The exit status of the shell is returned as an error.
+func (c *EnterCommand) enter(requestedContainerName string, options constants.CommonFlags) error {
 
 	//Find the requested container config and state
-	requestedContainerConfig, requestedContainerState := utils.GetContainerConfigAndState(c.Config, requestedContainerName, true, false) //it might not be present in the state file since in case of non-daemonized containers we might have to create them first
+	requestedContainerConfig, requestedContainerState, err := utils.GetContainerConfigAndState(c.Config, requestedContainerName, true, false) //it might not be present in the state file since in case of non-daemonized containers we might have to create them first
+	if err != nil {
+		return err
+	}
 
 	if requestedContainerConfig.Daemonized { //ssh into it and provide the user with an interactive shell
-		ssh.SshConnect(requestedContainerState.IP, requestedContainerConfig.Username, requestedContainerConfig.Password, constants.SHELL_COMMAND)
-	} else { //run the container and provide the user with an interactive shell
-		//Needs tty allocated
-		dockerCommand := container.BuildRunCommand(requestedContainerConfig, requestedContainerName, true, true)
-
-		if !options.ForceImage {
-			buildImageCommand := BuildImageCommand{Ui: c.Ui}
-			buildImageCommand.BuildImageIfNeeded(requestedContainerConfig)
-		} else {
-			logger.Debug("Force Image option detected. Will use host's system image.")
+		stateHost, err := utils.GetRequestedHost(c.Config.CraneConfig.Hosts, requestedContainerState.Host)
+		if err != nil {
+			return err
+		}
+		return ssh.SshEnter(requestedContainerState.SshAddress(stateHost), requestedContainerConfig.Username, requestedContainerConfig.Password, requestedContainerConfig.Graphical)
+	}
+
+	//run the container and provide the user with an interactive shell
+	//Needs tty allocated
+	host, err := utils.GetRequestedHost(c.Config.CraneConfig.Hosts, requestedContainerConfig.Host)
+	if err != nil {
+		return err
+	}
+
+	dockerCommand, err := container.BuildRunCommand(host, requestedContainerConfig, requestedContainerName, true, true)
+	if err != nil {
+		return err
+	}
+
+	if !options.ForceImage {
+		buildImageCommand := BuildImageCommand{Ui: c.Ui}
+		if err := buildImageCommand.PrepareImage(host, requestedContainerName, requestedContainerConfig); err != nil {
+			return err
 		}
-		dockerCommand = append(dockerCommand, constants.SHELL_COMMAND)
+	} else {
+		logger.Debug("Force Image option detected. Will use host's system image.")
+	}
 
-		executer.ExecuteCommand(dockerCommand)
+	if err := createNamedVolumes(host, requestedContainerName, requestedContainerConfig); err != nil {
+		return err
+	}
+	dockerCommand = append(dockerCommand, constants.SHELL_COMMAND)
+
+	shellErr := executer.ExecuteCommand(dockerCommand)
 
-		id := io.GetContainerIdFromFile(requestedContainerName)
+	//The container exists even if the shell exited with an error so it is recorded anyway
+	id, err := io.GetContainerIdFromFile(requestedContainerName)
+	if err != nil {
+		if shellErr != nil {
+			return shellErr
+		}
+		return err
+	}
+	trackCreatedContainer(requestedContainerName, id, host)
 
-		//Update the state file
-		io.UpdateStateFile(map[string]container.StateContainer{requestedContainerName: container.StateContainer{ID: id, IP: constants.NOT_DAEMONIZED_IP}})
+	//Update the state file
+	if err := io.UpdateStateFile(map[string]container.StateContainer{requestedContainerName: container.StateContainer{ID: id, IP: constants.NOT_DAEMONIZED_IP, Host: requestedContainerConfig.Host}}); err != nil {
+		return err
 	}
 
-	return 0
+	return shellErr
 }
 
 //Checks if provided arguments are valid.
-func checkArgumentsValidity(arguments []string) {
+func checkArgumentsValidity(arguments []string) error {
 
 	argumentCount := len(arguments)
 
 	if argumentCount == 0 {
-		logger.Fatal("No container name provided.Please correct")
+		return exit.Errorf(exit.USAGE_ERROR, "No container name provided.Please correct")
 	} else if argumentCount > 1 {
-		logger.Fatal("Too many arguments provided. You can enter only one container at a time.Please correct.")
+		return exit.Errorf(exit.USAGE_ERROR, "Too many arguments provided. You can enter only one container at a time.Please correct.")
 	}
+	return nil
 }
 
 func (c *EnterCommand) Synopsis() string {
@@ -0,0 +1,15 @@
+package command
+
+import (
+	"github.com/SnowRipple/crane/exit"
+	"github.com/SnowRipple/crane/output"
+)
+
+//Reports the error that ended a command and returns the exit code crane should finish with.
+func exitWithError(err error) int {
+
+	logger.Error("%s", err.Error())
+	output.SetError(err.Error())
+
+	return exit.Code(err)
+}
@@ -0,0 +1,167 @@
+package executer
+
+import (
+	"github.com/SnowRipple/crane/container"
+	"github.com/SnowRipple/crane/exit"
+	"net"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+/*
+ Privilege escalation strategies used to run docker commands
+*/
+const (
+	ESCALATION_AUTO   = "auto"   //none if the docker socket is accessible, sudo otherwise
+	ESCALATION_NONE   = "none"   //docker group members, rootless docker, CI
+	ESCALATION_SUDO   = "sudo"   //prefix every docker command with sudo
+	ESCALATION_CUSTOM = "custom" //prefix every docker command with a custom wrapper (e.g. "doas", "sudo -n")
+)
+
+const (
+	DOCKER_HOST_VARIABLE = "DOCKER_HOST"
+	DOCKER_SOCKET        = "/var/run/docker.sock"
+	UNIX_SOCKET_PREFIX   = "unix://"
+	SOCKET_CHECK_TIMEOUT = 1 * time.Second
+	DEFAULT_ESCALATION   = ESCALATION_AUTO
+)
+
+//Chosen escalation strategy and the wrapper command of the custom one.
+var (
+	escalationStrategy = ESCALATION_SUDO
+	customEscalation   []string
+)
+
+//Escalations detected by the auto strategy for every runtime and daemon socket.
+var (
+	detectedEscalations      = map[string][]string{}
+	detectedEscalationsMutex sync.Mutex
+)
+
+//Chooses how docker commands gain the privileges they need.
+//A custom wrapper command implies the custom strategy when no strategy is given.
+func SetEscalation(strategy, customCommand string) error {
+
+	if len(strategy) == 0 {
+		strategy = DEFAULT_ESCALATION
+		if len(strings.TrimSpace(customCommand)) > 0 {
+			strategy = ESCALATION_CUSTOM
+		}
+	}
+
+	switch strategy {
+	case ESCALATION_NONE, ESCALATION_SUDO, ESCALATION_AUTO:
+	case ESCALATION_CUSTOM:
+		customEscalation = strings.Fields(customCommand)
+		if len(customEscalation) == 0 {
+			return exit.Errorf(exit.USAGE_ERROR, "The %q escalation requires a wrapper command (--escalation-command).", ESCALATION_CUSTOM)
+		}
+	default:
+		return exit.Errorf(exit.USAGE_ERROR, "Unknown escalation %q.Please use one of: %s, %s, %s or %s.", strategy, ESCALATION_AUTO, ESCALATION_NONE, ESCALATION_SUDO, ESCALATION_CUSTOM)
+	}
+
+	logger.Debug("Using %q privilege escalation", strategy)
+	escalationStrategy = strategy
+	return nil
+}
+
+//Returns the command prepended to a docker command, empty when docker is used directly.
+//The auto strategy is resolved on the first use of every runtime and daemon socket so it takes the container runtime
+//chosen in the Cranefile and the daemon of the command's host into account.
+func currentEscalation(command []string) []string {
+
+	switch escalationStrategy {
+	case ESCALATION_NONE:
+		return nil
+	case ESCALATION_CUSTOM:
+		return customEscalation
+	case ESCALATION_AUTO:
+		socket := daemonSocket(command)
+		key := escalationKey(socket)
+
+		detectedEscalationsMutex.Lock()
+		defer detectedEscalationsMutex.Unlock()
+
+		escalation, detected := detectedEscalations[key]
+		if !detected {
+			escalation = detectEscalation(socket)
+			detectedEscalations[key] = escalation
+			logger.Debug("Docker commands of %q will be prefixed with %q", key, strings.Join(escalation, " "))
+		}
+		return escalation
+	}
+	return []string{SUDO}
+}
+
+//Identifies a daemon of the chosen runtime for the escalation detection.
+func escalationKey(socket string) string {
+	return containerRuntime + " " + socket
+}
+
+//Returns the unix socket of the daemon a docker command is sent to: the host's one, DOCKER_HOST or the default socket.
+//Empty when the daemon is reached over the network.
+func daemonSocket(command []string) string {
+
+	address := os.Getenv(DOCKER_HOST_VARIABLE)
+	for _, argument := range command {
+		if strings.HasPrefix(argument, container.HOST_OPTION) {
+			address = strings.TrimPrefix(argument, container.HOST_OPTION)
+			break
+		}
+	}
+
+	if len(address) == 0 {
+		return DOCKER_SOCKET
+	}
+	if !strings.HasPrefix(address, UNIX_SOCKET_PREFIX) {
+		return ""
+	}
+	return strings.TrimPrefix(address, UNIX_SOCKET_PREFIX)
+}
+
+//Uses docker directly if its socket is accessible (or docker is remote), sudo otherwise.
+//Podman runs rootless so it never needs the escalation.
+func detectEscalation(socket string) []string {
+
+	if containerRuntime == RUNTIME_PODMAN {
+		logger.Debug("Podman runs rootless, no privilege escalation needed.")
+		return nil
+	}
+
+	if len(socket) == 0 {
+		logger.Debug("Docker is reached over the network, no privilege escalation needed.")
+		return nil
+	}
+
+	if connection, err := net.DialTimeout("unix", socket, SOCKET_CHECK_TIMEOUT); err == nil {
+		connection.Close()
+		logger.Debug("Docker socket %q is accessible, no privilege escalation needed.", socket)
+		return nil
+	} else {
+		logger.Debug("Docker socket %q is not accessible: %v", socket, err)
+	}
+
+	if _, err := exec.LookPath(SUDO); err != nil {
+		logger.Debug("Sudo is not available, docker will be used directly.")
+		return nil
+	}
+	return []string{SUDO}
+}
+
+//Prepends the privilege escalation to a command.
+func escalate(command []string) []string {
+	return append(append([]string{}, currentEscalation(command)...), command...)
+}
+
+//Creates a command run by the chosen container runtime with the chosen privilege escalation (e.g. to be piped with PipeCommands).
+func Command(command ...string) (*exec.Cmd, error) {
+
+	argv, err := prepare(command)
+	if err != nil {
+		return nil, err
+	}
+	return exec.Command(argv[0], argv[1:]...), nil
+}
@@ -0,0 +1,47 @@
+package executer
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCurrentEscalation_perDaemon(t *testing.T) {
+
+	defer func(strategy string, detected map[string][]string) {
+		escalationStrategy = strategy
+		detectedEscalations = detected
+	}(escalationStrategy, detectedEscalations)
+	t.Setenv(DOCKER_HOST_VARIABLE, "")
+
+	escalationStrategy = ESCALATION_AUTO
+	detectedEscalations = map[string][]string{
+		escalationKey(DOCKER_SOCKET):                {SUDO},
+		escalationKey("/run/user/1000/docker.sock"): nil,
+	}
+
+	tests := []struct {
+		command  []string
+		expected []string
+	}{
+		{[]string{"docker", "ps"}, []string{SUDO}},
+		{[]string{"docker", "-H=unix:///run/user/1000/docker.sock", "ps"}, nil},
+		{[]string{"docker", "-H=tcp://build.example.com:2376", "ps"}, nil},
+	}
+	for _, test := range tests {
+		if escalation := currentEscalation(test.command); !reflect.DeepEqual(escalation, test.expected) {
+			t.Errorf("currentEscalation(%q) = %q; expected %q", test.command, escalation, test.expected)
+		}
+	}
+}
+
+func TestDaemonSocket(t *testing.T) {
+
+	t.Setenv(DOCKER_HOST_VARIABLE, "unix:///var/run/custom.sock")
+
+	if socket := daemonSocket([]string{"docker", "ps"}); socket != "/var/run/custom.sock" {
+		t.Errorf("DOCKER_HOST socket = %q; expected %q", socket, "/var/run/custom.sock")
+	}
+	if socket := daemonSocket([]string{"docker", "-H=ssh://deploy@build.example.com", "ps"}); socket != "" {
+		t.Errorf("Remote host socket = %q; expected none", socket)
+	}
+}
@@ -1,72 +1,344 @@
 package executer
 
 import (
+	"bytes"
+	"github.com/SnowRipple/crane/cancellation"
+	"github.com/SnowRipple/crane/dryrun"
+	"github.com/SnowRipple/crane/exit"
 	log "github.com/SnowRipple/crane/logger"
+	"github.com/SnowRipple/crane/terminal"
 	"github.com/SnowRipple/crane/utils"
 	"io"
 	"os"
 	"os/exec"
+	"strings"
+	"syscall"
+	"time"
 )
 
 const SUDO = "sudo"
 
+//How long a terminated command has to finish before it is killed.
+const KILL_GRACE_PERIOD = 10 * time.Second
+
 var logger = log.GetLogger()
 
 func GetCommandOutput(command []string) ([]byte, error) {
+	return GetCommandOutputWithTimeout(command, 0)
+}
+
+//Executes a command and returns its combined output.The command is terminated when it does not finish within the timeout (0 means no timeout) or crane is cancelled.
+func GetCommandOutputWithTimeout(command []string, timeout time.Duration) ([]byte, error) {
 
 	logger.Debug("\nFinal docker command: %v\n", command)
 
-	//CombinedOutput is needed so errors can be returned as well
-	return exec.Command(SUDO, command...).CombinedOutput()
+	argv, err := prepare(command)
+	if err != nil {
+		return nil, err
+	}
+
+	defer log.ScopeField(log.DOCKER_ARGV_FIELD, argv)()
+
+	if dryrun.Enabled() {
+		return []byte(printDryRunCommand(argv, command)), nil
+	}
+
+	//Combined output is needed so errors can be returned as well
+	var output bytes.Buffer
+	cmd := exec.Command(argv[0], argv[1:]...)
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+
+	if err := start(cmd); err != nil {
+		return nil, err
+	}
+
+	err = wait(cmd, timeout)
+	return output.Bytes(), err
 }
 
-func ExecuteCommand(command []string) {
+//Executes a command and streams its combined output to a writer while it runs (e.g. progress of docker build).
+//The output is returned as well.
+func GetStreamedCommandOutput(command []string, writer io.Writer) ([]byte, error) {
 
 	logger.Debug("\nFinal docker command: %v\n", command)
 
-	cmd := exec.Command(SUDO, command...)
+	argv, err := prepare(command)
+	if err != nil {
+		return nil, err
+	}
+
+	defer log.ScopeField(log.DOCKER_ARGV_FIELD, argv)()
 
-	stdin, err := cmd.StdinPipe()
+	if dryrun.Enabled() {
+		return []byte(printDryRunCommand(argv, command)), nil
+	}
+
+	var output bytes.Buffer
+	cmd := exec.Command(argv[0], argv[1:]...)
+	cmd.Stdout = io.MultiWriter(&output, writer)
+	cmd.Stderr = cmd.Stdout
+
+	if err := start(cmd); err != nil {
+		return nil, err
+	}
+
+	err = wait(cmd, 0)
+	return output.Bytes(), err
+}
+
+//Executes a command attached directly to crane's terminal (used for interactive docker sessions like "docker run -i -t").
+//The local terminal is put into raw mode for the duration of the command and restored afterwards.
+//Docker client shares the controlling terminal with crane so it picks up window resizes (SIGWINCH) on its own.
+//Non-zero exit status of the command is returned as an error carrying the same exit code.
+func ExecuteCommand(command []string) error {
+
+	logger.Debug("\nFinal docker command: %v\n", command)
+
+	argv, err := prepare(command)
 	if err != nil {
-		logger.Fatal("Failed to set up command's stdin", err)
+		return err
 	}
 
-	stderr, err := cmd.StderrPipe()
+	defer log.ScopeField(log.DOCKER_ARGV_FIELD, argv)()
+
+	if dryrun.Enabled() {
+		printDryRunCommand(argv, command)
+		return nil
+	}
+
+	cmd := exec.Command(argv[0], argv[1:]...)
+
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	oldState, err := terminal.MakeTerminalRaw()
 	if err != nil {
-		logger.Fatal("Failed to set up command's stderr", err)
+		return err
 	}
+	defer terminal.RestoreTerminal(oldState)
 
-	stdout, err := cmd.StdoutPipe()
+	if err := start(cmd); err != nil {
+		return exit.Errorf(exit.DOCKER_ERROR, "Failed to start the command: %v", err)
+	}
+
+	if err := wait(cmd, 0); err != nil {
+		logger.Debug("Interactive command finished with error: %v", err)
+		return ExitStatusError(err)
+	}
+	return nil
+}
+
+//Prints a command (as it would be executed) instead of executing it and returns a placeholder for the output of the original command.
+func printDryRunCommand(argv, command []string) string {
+
+	dryrun.PrintCommand(argv)
+	return dryRunPlaceholder(command)
+}
+
+//Returns a placeholder for the output of a command that was not executed: "docker run ..." -> "<output of docker run>".
+func dryRunPlaceholder(command []string) string {
+
+	if len(command) > 2 {
+		command = command[:2]
+	}
+	return dryrun.Placeholder("output of " + strings.Join(command, " "))
+}
+
+//Executes a command even if crane has been cancelled (used to clean up after the cancellation).
+//The command has KILL_GRACE_PERIOD to finish.
+func GetCleanupCommandOutput(command []string) ([]byte, error) {
+
+	logger.Debug("\nFinal cleanup command: %v\n", command)
+
+	argv, err := prepare(command)
+	if err != nil {
+		return nil, err
+	}
+
+	if dryrun.Enabled() {
+		return []byte(printDryRunCommand(argv, command)), nil
+	}
+
+	var output bytes.Buffer
+	cmd := exec.Command(argv[0], argv[1:]...)
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	finished := make(chan error, 1)
+	go func() { finished <- cmd.Wait() }()
+
+	select {
+	case err := <-finished:
+		return output.Bytes(), err
+	case <-time.After(KILL_GRACE_PERIOD):
+		cmd.Process.Kill()
+		return output.Bytes(), exit.Errorf(exit.TIMEOUT, "Cleanup command did not finish within %v", KILL_GRACE_PERIOD)
+	}
+}
+
+//Starts a command unless crane has already been cancelled.
+func start(cmd *exec.Cmd) error {
+
+	if err := cancellation.Err(); err != nil {
+		return err
+	}
+	return cmd.Start()
+}
+
+//Waits for a started command to finish.
+//When crane is cancelled or the timeout (0 means no timeout) expires the command is terminated and the reason is returned.
+func wait(cmd *exec.Cmd, timeout time.Duration) error {
+
+	finished := make(chan error, 1)
+	go func() { finished <- cmd.Wait() }()
+
+	cancelled, stop := cancellation.WithTimeout(timeout)
+
+	select {
+	case err := <-finished:
+		stop()
+		return err
+	case <-cancelled:
+	}
+
+	reason := stop()
+	logger.Debug("Terminating %v: %v", cmd.Args, reason)
+
+	//Sudo passes SIGTERM on to the command, SIGKILL would leave the command running when escalation is used
+	cmd.Process.Signal(syscall.SIGTERM)
+	select {
+	case <-finished:
+	case <-time.After(KILL_GRACE_PERIOD):
+		logger.Debug("%v did not finish within %v, killing it", cmd.Args, KILL_GRACE_PERIOD)
+		cmd.Process.Kill()
+		<-finished
+	}
+	return reason
+}
+
+//Converts the error of a finished command into an error carrying the command's exit status.
+func ExitStatusError(err error) error {
+
+	if _, ok := err.(*exit.Error); ok { //Cancelled or timed out
+		return err
+	}
+
+	if exitError, ok := err.(*exec.ExitError); ok {
+		if status, ok := exitError.Sys().(syscall.WaitStatus); ok {
+			return exit.Errorf(status.ExitStatus(), "Command exited with status %d", status.ExitStatus())
+		}
+	}
+	return exit.Errorf(exit.DOCKER_ERROR, "Command failed: %v", err)
+}
+
+//Stream connected to the standard input and output of a running command.
+type commandStream struct {
+	command *exec.Cmd
+	stdin   io.WriteCloser
+	stdout  io.ReadCloser
+}
+
+func (stream *commandStream) Read(bytes []byte) (int, error) {
+	return stream.stdout.Read(bytes)
+}
+
+func (stream *commandStream) Write(bytes []byte) (int, error) {
+	return stream.stdin.Write(bytes)
+}
+
+//Closes the command's input only, its output can still be read.
+func (stream *commandStream) CloseWrite() error {
+	return stream.stdin.Close()
+}
+
+//Closes the command's input and waits for the command to finish.
+func (stream *commandStream) Close() error {
+	stream.stdin.Close()
+	return stream.command.Wait()
+}
+
+//Starts a command and returns a stream connected to its standard input and output (e.g. to tunnel network connections through it).
+func StartStreamCommand(command []string) (io.ReadWriteCloser, error) {
+
+	logger.Debug("\nFinal docker command: %v\n", command)
+
+	argv, err := prepare(command)
 	if err != nil {
-		logger.Fatal("Failed to set up command's stdout", err)
+		return nil, err
 	}
 
-	go io.Copy(os.Stdout, stdout)
-	go io.Copy(stdin, os.Stdin)
-	go io.Copy(os.Stderr, stderr)
+	defer log.ScopeField(log.DOCKER_ARGV_FIELD, argv)()
+
+	cmd := exec.Command(argv[0], argv[1:]...)
 
-	if err = cmd.Start(); err != nil {
-		logger.Fatal("Failed to start the command", err)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
 	}
-	defer cmd.Wait()
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	return &commandStream{command: cmd, stdin: stdin, stdout: stdout}, nil
 }
 
 //Pipe multiple commands in a unix fashion
-func PipeCommands(commands ...*exec.Cmd) string {
+func PipeCommands(commands ...*exec.Cmd) (string, error) {
+
+	var argv []string
+	for _, command := range commands {
+		argv = append(argv, command.Args...)
+		argv = append(argv, "|")
+	}
+	defer log.ScopeField(log.DOCKER_ARGV_FIELD, argv[:len(argv)-1])()
+
+	if dryrun.Enabled() {
+		var pipeline []string
+		for _, command := range commands {
+			pipeline = append(pipeline, dryrun.Quote(command.Args))
+		}
+		dryrun.Print("%s", strings.Join(pipeline, " | "))
+		return dryRunPlaceholder(commands[0].Args[len(currentEscalation(commands[0].Args)):]), nil
+	}
 
 	//Connect command's stdout with the next command's stdin
 	for index, command := range commands[:len(commands)-1] {
 		stdout, err := command.StdoutPipe()
 		if err != nil {
-			logger.Fatal("Piping commands:Failed to set up command's stdout", err)
+			return "", exit.Errorf(exit.FAILURE, "Piping commands:Failed to set up command's stdout: %v", err)
+		}
+		if err := start(command); err != nil {
+			return "", exit.Errorf(exit.DOCKER_ERROR, "Piping commands:Failed to start the command: %v", err)
 		}
-		command.Start()
 		commands[index+1].Stdin = stdout
 	}
 
-	output, err := commands[len(commands)-1].CombinedOutput()
+	var output bytes.Buffer
+	lastCommand := commands[len(commands)-1]
+	lastCommand.Stdout = &output
+	lastCommand.Stderr = &output
+
+	err := start(lastCommand)
+	if err == nil {
+		err = wait(lastCommand, 0)
+	}
 	if err != nil {
-		logger.Fatal("Piping commands:Failed to get output of the final command", utils.ExtractContainerMessage(output, err))
+		if _, ok := err.(*exit.Error); ok { //Cancelled
+			return "", err
+		}
+		return "", exit.Errorf(exit.DOCKER_ERROR, "Piping commands:Failed to get output of the final command:%s", utils.ExtractContainerMessage(output.Bytes(), err))
 	}
-	return string(output)
+	return output.String(), nil
 }
@@ -0,0 +1,45 @@
+package executer
+
+import (
+	"github.com/SnowRipple/crane/constants"
+	"github.com/SnowRipple/crane/exit"
+	"strings"
+)
+
+//Docker commands reaching image registries.
+var registryCommands = map[string]bool{constants.PULL: true, constants.SEARCH: true}
+
+//In the offline mode image registries are never reached, images have to exist on the host or be built.
+var offline bool
+
+//Enables the offline mode, either for this host (--offline or CRANE_OFFLINE) or for the project (OFFLINE in the Cranefile).
+func EnableOffline(enabled bool) {
+
+	if enabled && !offline {
+		logger.Debug("Offline mode enabled, image registries won't be reached")
+		offline = true
+	}
+}
+
+//Checks if the offline mode is enabled.
+func Offline() bool {
+	return offline
+}
+
+//Refuses docker commands that reach image registries in the offline mode so they fail fast instead of hanging.
+func checkOffline(command []string) error {
+
+	if !offline || len(command) == 0 || command[0] != constants.DOCKER {
+		return nil
+	}
+	for _, argument := range command[1:] {
+		if strings.HasPrefix(argument, "-") { //Options selecting the daemon
+			continue
+		}
+		if registryCommands[argument] {
+			return exit.Errorf(exit.CONFIG_ERROR, "\"%s %s\" needs an image registry which is not reachable in the offline mode.", constants.DOCKER, argument)
+		}
+		return nil
+	}
+	return nil
+}
@@ -0,0 +1,150 @@
+package executer
+
+import (
+	"github.com/SnowRipple/crane/constants"
+	"github.com/SnowRipple/crane/container"
+	"github.com/SnowRipple/crane/exit"
+	"os"
+	"strconv"
+	"strings"
+)
+
+/*
+ Supported container runtimes
+*/
+const (
+	RUNTIME_DOCKER = "docker"
+	RUNTIME_PODMAN = "podman"
+)
+
+//Lowest port rootless Podman can't publish on the host.
+const PRIVILEGED_PORTS_LIMIT = 1024
+
+//Docker options Podman spells differently.
+var podmanOptions = map[string]string{
+	container.HOST_OPTION: "--url=",
+}
+
+//Runtime executing the docker commands and whether it was chosen for this host (it wins over the Cranefile then).
+var (
+	containerRuntime     = RUNTIME_DOCKER
+	runtimeChosenForHost bool
+)
+
+//Chooses the container runtime for this host (--runtime or CRANE_RUNTIME).It takes precedence over the Cranefile.
+func SetRuntime(name string) error {
+
+	if len(name) == 0 {
+		return nil
+	}
+	if err := setRuntime(name); err != nil {
+		return err
+	}
+	runtimeChosenForHost = true
+	return nil
+}
+
+//Chooses the container runtime of the project (RUNTIME in the Cranefile) unless one was chosen for this host.
+func SetProjectRuntime(name string) error {
+
+	if len(name) == 0 || runtimeChosenForHost {
+		return nil
+	}
+	return setRuntime(name)
+}
+
+func setRuntime(name string) error {
+
+	switch name {
+	case RUNTIME_DOCKER, RUNTIME_PODMAN:
+		logger.Debug("Using %q container runtime", name)
+		containerRuntime = name
+		return nil
+	}
+	return exit.Errorf(exit.CONFIG_ERROR, "Unknown container runtime %q.Please use %q or %q.", name, RUNTIME_DOCKER, RUNTIME_PODMAN)
+}
+
+//Returns the name of the chosen container runtime.
+func Runtime() string {
+	return containerRuntime
+}
+
+//Translates a docker command for the chosen runtime and prepends the privilege escalation.
+func prepare(command []string) ([]string, error) {
+
+	if err := checkOffline(command); err != nil {
+		return nil, err
+	}
+
+	remote := isRemoteDaemon(command)
+
+	if containerRuntime == RUNTIME_PODMAN && len(command) > 0 && command[0] == constants.DOCKER {
+		podmanCommand, err := translateForPodman(command)
+		if err != nil {
+			return nil, err
+		}
+		command = podmanCommand
+	}
+
+	if remote && escalationStrategy == ESCALATION_AUTO { //The remote daemon decides about privileges, sudo would only swap the ssh keys for root's
+		return command, nil
+	}
+	return escalate(command), nil
+}
+
+//Returns true if a docker command is sent to a daemon on another machine (-H with a tcp:// or ssh:// address).
+func isRemoteDaemon(command []string) bool {
+
+	for _, argument := range command {
+		if strings.HasPrefix(argument, container.HOST_OPTION) {
+			return !strings.HasPrefix(strings.TrimPrefix(argument, container.HOST_OPTION), container.UNIX_ADDRESS_PREFIX)
+		}
+	}
+	return false
+}
+
+//Rewrites a docker command into the equivalent Podman command.
+//Options rootless Podman can't honour are reported instead of failing later with an obscure error.
+func translateForPodman(command []string) ([]string, error) {
+
+	podmanCommand := []string{RUNTIME_PODMAN}
+	rootless := len(currentEscalation(command)) == 0 && os.Geteuid() != 0
+
+	for _, argument := range command[1:] {
+		if strings.HasPrefix(argument, container.TLS_VERIFY_OPTION) {
+			return nil, exit.Errorf(exit.CONFIG_ERROR, "Podman does not support TLS connections to remote hosts.Please use an ssh:// address for the host instead.")
+		}
+
+		for dockerOption, podmanOption := range podmanOptions {
+			if argument == dockerOption || (strings.HasSuffix(dockerOption, "=") && strings.HasPrefix(argument, dockerOption)) {
+				argument = podmanOption + strings.TrimPrefix(argument, dockerOption)
+				break
+			}
+		}
+
+		if rootless && strings.HasPrefix(argument, container.PORT_REDIRECT_OPTION) {
+			if err := checkRootlessPort(argument); err != nil {
+				return nil, err
+			}
+		}
+
+		podmanCommand = append(podmanCommand, argument)
+	}
+
+	return podmanCommand, nil
+}
+
+//Rootless Podman can't bind host ports below 1024.Auto-assigned host ports are always above.
+func checkRootlessPort(portOption string) error {
+
+	port, err := container.ParsePort(strings.TrimPrefix(portOption, container.PORT_REDIRECT_OPTION))
+	if err != nil || len(port.HostPort) == 0 {
+		return nil
+	}
+
+	hostPort, err := strconv.Atoi(strings.SplitN(port.HostPort, container.RANGE_DELIMITER, 2)[0]) //First port of a range is the lowest
+	if err != nil || hostPort >= PRIVILEGED_PORTS_LIMIT {
+		return nil
+	}
+	return exit.Errorf(exit.CONFIG_ERROR, "Rootless Podman can't publish host port %d (ports below %d are privileged).Please use a higher port in the Cranefile or run Podman as root (--escalation=sudo).", hostPort, PRIVILEGED_PORTS_LIMIT)
+}
@@ -0,0 +1,71 @@
+package executer
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTranslateForPodman(t *testing.T) {
+
+	defer func(strategy string) { escalationStrategy = strategy }(escalationStrategy)
+	escalationStrategy = ESCALATION_SUDO //Not rootless so privileged ports are allowed
+
+	command := []string{"docker", "-H=ssh://deploy@build.example.com", "run", "-i", "--privileged", "--cidfile=.cidfileweb", "--dns=8.8.8.8", "-p=80:8080", "-v=/home:/home:rw", "ubuntu"}
+	expected := []string{"podman", "--url=ssh://deploy@build.example.com", "run", "-i", "--privileged", "--cidfile=.cidfileweb", "--dns=8.8.8.8", "-p=80:8080", "-v=/home:/home:rw", "ubuntu"}
+
+	translated, err := translateForPodman(command)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(translated, expected) {
+		t.Errorf("translateForPodman(%q) = %q; expected %q", command, translated, expected)
+	}
+}
+
+func TestCheckRootlessPort(t *testing.T) {
+
+	if err := checkRootlessPort("-p=8080:80"); err != nil {
+		t.Errorf("Unexpected error for an unprivileged port: %v", err)
+	}
+	if err := checkRootlessPort("-p=127.0.0.1::80/tcp"); err != nil {
+		t.Errorf("Auto-assigned port rejected: %v", err)
+	}
+	if err := checkRootlessPort("-p=127.0.0.1:443-445:443-445/tcp"); err == nil {
+		t.Errorf("Privileged port range accepted")
+	}
+	if err := checkRootlessPort("-p=80:80"); err == nil {
+		t.Errorf("Expected an error for a privileged port")
+	}
+}
+
+func TestIsRemoteDaemon(t *testing.T) {
+
+	if isRemoteDaemon([]string{"docker", "ps"}) {
+		t.Errorf("The local daemon is not remote")
+	}
+	if isRemoteDaemon([]string{"docker", "-H=unix:///var/run/docker.sock", "ps"}) {
+		t.Errorf("A unix socket is not remote")
+	}
+	if !isRemoteDaemon([]string{"docker", "-H=ssh://deploy@build.example.com", "ps"}) {
+		t.Errorf("An ssh address is remote")
+	}
+}
+
+func TestCheckOffline(t *testing.T) {
+
+	defer func() { offline = false }()
+	EnableOffline(true)
+
+	if err := checkOffline([]string{"docker", "-H=ssh://build", "pull", "postgres"}); err == nil {
+		t.Errorf("Pull allowed in the offline mode")
+	}
+	if err := checkOffline([]string{"docker", "search", "postgres"}); err == nil {
+		t.Errorf("Search allowed in the offline mode")
+	}
+	if err := checkOffline([]string{"docker", "run", "-i", "postgres", "pull"}); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if err := checkOffline([]string{"docker", "inspect", "--type=image", "postgres"}); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,229 @@
+package command
+
+import (
+	"flag"
+	"github.com/SnowRipple/crane/command/executer"
+	"github.com/SnowRipple/crane/config"
+	"github.com/SnowRipple/crane/constants"
+	"github.com/SnowRipple/crane/container"
+	"github.com/SnowRipple/crane/dryrun"
+	"github.com/SnowRipple/crane/exit"
+	ownLog "github.com/SnowRipple/crane/logger"
+	"github.com/SnowRipple/crane/ssh"
+	"github.com/SnowRipple/crane/utils"
+	"github.com/mitchellh/cli"
+	"io"
+	"net"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+const (
+	FORWARD_LOCAL_HOST     = "127.0.0.1"
+	FORWARD_ARGUMENT_COUNT = 2
+)
+
+// ForwardCommand tunnels local ports into a container.
+type ForwardCommand struct {
+	Ui     cli.Ui
+	Config config.TomlConfig
+}
+
+//Single local port forwarded to a port inside a container.
+type portForward struct {
+	localPort     int
+	containerPort int
+}
+
+//Opens a new connection to a port inside a container.
+type containerDialer func(containerPort int) (io.ReadWriteCloser, error)
+
+func (c *ForwardCommand) Help() string {
+	helpText := `
+  Usage: crane forward <containerName> <localPort1>:<containerPort1> <localPort2>:<containerPort2>
+
+  Opens local TCP ports (on 127.0.0.1) tunneled into chosen ports of a running container, so services that are not published (debuggers, admin consoles) can be reached without recreating the container.
+  Tunnels are kept open until crane is interrupted (Ctrl-C).
+
+  Daemonized containers are tunneled over ssh.
+  Other containers are tunneled through docker exec, they have to be running and have "nc" installed.
+  `
+	return strings.TrimSpace(helpText)
+}
+
+//Forward local ports into a container until interrupted.
+func (c *ForwardCommand) Run(arguments []string) int {
+
+	logger.Debug("Entered forward command...")
+
+	cmdFlags := flag.NewFlagSet("forward", flag.ContinueOnError)
+	cmdFlags.Usage = func() { c.Ui.Output(c.Help()) }
+
+	if len(arguments) < FORWARD_ARGUMENT_COUNT {
+		return exitWithError(exit.Errorf(exit.USAGE_ERROR, "Missing arguments.Please specify a container and at least one <localPort>:<containerPort> pair."))
+	}
+
+	containerName := arguments[0]
+	defer ownLog.ScopeField(ownLog.CONTAINER_FIELD, containerName)()
+
+	forwards, err := extractPortForwards(arguments[1:])
+	if err != nil {
+		return exitWithError(err)
+	}
+
+	containerConfig, containerState, err := utils.GetContainerConfigAndState(c.Config, containerName, true, true) //Container must be created by crane first
+	if err != nil {
+		return exitWithError(err)
+	}
+	host, err := utils.GetRequestedHost(c.Config.CraneConfig.Hosts, containerState.Host)
+	if err != nil {
+		return exitWithError(err)
+	}
+	dialer := buildContainerDialer(host, containerConfig, containerState)
+
+	if dryrun.Enabled() {
+		for _, forward := range forwards {
+			dryrun.Print("forward %s to port %d of container %q", net.JoinHostPort(FORWARD_LOCAL_HOST, strconv.Itoa(forward.localPort)), forward.containerPort, containerName)
+		}
+		return 0
+	}
+
+	var listeners []net.Listener
+
+	closeListeners := func() {
+		for _, listener := range listeners {
+			listener.Close()
+		}
+	}
+
+	for _, forward := range forwards {
+		localAddress := net.JoinHostPort(FORWARD_LOCAL_HOST, strconv.Itoa(forward.localPort))
+
+		listener, err := net.Listen("tcp", localAddress)
+		if err != nil {
+			closeListeners()
+			return exitWithError(exit.Errorf(exit.FAILURE, "Failed to listen on %q due to error: %v", localAddress, err))
+		}
+		listeners = append(listeners, listener)
+
+		logger.Notice("Forwarding %s to port %d of container %q", localAddress, forward.containerPort, containerName)
+		go acceptForwardedConnections(listener, forward.containerPort, dialer)
+	}
+
+	//Keep tunnels open until the user interrupts crane
+	interrupted := make(chan os.Signal, 1)
+	signal.Notify(interrupted, os.Interrupt, syscall.SIGTERM)
+	<-interrupted
+
+	logger.Notice("Interrupted, closing forwarded ports...")
+	closeListeners()
+
+	return 0
+}
+
+//Parses <localPort>:<containerPort> pairs.
+func extractPortForwards(arguments []string) ([]portForward, error) {
+
+	var forwards []portForward
+
+	for _, argument := range arguments {
+		ports := strings.Split(argument, constants.COMMANDS_DELIMITER)
+		if len(ports) != container.PORTS_ARGUMENT_COUNT {
+			return nil, exit.Errorf(exit.USAGE_ERROR, "Wrong port forward format %q.Please use <localPort>:<containerPort>.", argument)
+		}
+
+		localPort, err := strconv.Atoi(ports[0])
+		if err != nil {
+			return nil, exit.Errorf(exit.USAGE_ERROR, "Invalid local port %q: %v", ports[0], err)
+		}
+
+		containerPort, err := strconv.Atoi(ports[1])
+		if err != nil {
+			return nil, exit.Errorf(exit.USAGE_ERROR, "Invalid container port %q: %v", ports[1], err)
+		}
+
+		forwards = append(forwards, portForward{localPort: localPort, containerPort: containerPort})
+	}
+
+	return forwards, nil
+}
+
+//Chooses how connections reach the container: over ssh for daemonized containers, through docker exec for others.
+func buildContainerDialer(host container.Host, containerConfig container.Container, containerState container.StateContainer) containerDialer {
+
+	if containerConfig.Daemonized {
+		return func(containerPort int) (io.ReadWriteCloser, error) {
+			return ssh.DialContainerPort(containerState.SshAddress(host), containerConfig.Username, containerConfig.Password, containerPort)
+		}
+	}
+
+	return func(containerPort int) (io.ReadWriteCloser, error) {
+		dockerCommand := host.DockerCommand(constants.EXEC, container.INTERACTIVE_OPTION, containerState.ID, constants.NETCAT_COMMAND, ssh.FORWARD_HOST, strconv.Itoa(containerPort))
+		return executer.StartStreamCommand(dockerCommand)
+	}
+}
+
+//Tunnels every accepted local connection into the container until the listener is closed.
+func acceptForwardedConnections(listener net.Listener, containerPort int, dialer containerDialer) {
+
+	for {
+		localConnection, err := listener.Accept()
+		if err != nil {
+			logger.Debug("Stopped accepting connections on %q: %v", listener.Addr(), err)
+			return
+		}
+
+		remoteConnection, err := dialer(containerPort)
+		if err != nil {
+			logger.Error("Failed to connect to port %d inside the container: %v", containerPort, err)
+			localConnection.Close()
+			continue
+		}
+
+		logger.Debug("Tunneling connection from %q to container port %d", localConnection.RemoteAddr(), containerPort)
+		go tunnel(localConnection, remoteConnection)
+	}
+}
+
+//Copies data both ways until both sides are done.A side that stopped sending only half-closes its peer,
+//so protocols that shut down writing first still receive the whole answer.
+func tunnel(localConnection net.Conn, remoteConnection io.ReadWriteCloser) {
+
+	defer localConnection.Close()
+	defer remoteConnection.Close()
+
+	done := make(chan bool, 2)
+
+	go func() {
+		io.Copy(remoteConnection, localConnection)
+		closeWrite(remoteConnection)
+		done <- true
+	}()
+	go func() {
+		io.Copy(localConnection, remoteConnection)
+		closeWrite(localConnection)
+		done <- true
+	}()
+
+	<-done
+	<-done
+}
+
+//Closes the writing half of a connection.Connections that can't be half-closed are closed entirely.
+func closeWrite(connection io.Closer) {
+
+	if halfCloser, ok := connection.(interface{ CloseWrite() error }); ok {
+		if err := halfCloser.CloseWrite(); err != nil {
+			logger.Debug("Failed to half-close the tunnelled connection: %v", err)
+		}
+		return
+	}
+	connection.Close()
+}
+
+func (c *ForwardCommand) Synopsis() string {
+	return "Forward local ports into a container."
+}
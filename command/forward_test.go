@@ -0,0 +1,56 @@
+package command
+
+import (
+	"io/ioutil"
+	"net"
+	"testing"
+)
+
+//Returns both ends of a loopback TCP connection.
+func connectionPair(t *testing.T) (client, server *net.TCPConn) {
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+
+	accepted := make(chan net.Conn, 1)
+	go func() {
+		connection, _ := listener.Accept()
+		accepted <- connection
+	}()
+
+	connection, err := net.Dial("tcp", listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return connection.(*net.TCPConn), (<-accepted).(*net.TCPConn)
+}
+
+func TestTunnel_halfClose(t *testing.T) {
+
+	client, localConnection := connectionPair(t)
+	remoteConnection, server := connectionPair(t)
+	defer client.Close()
+	defer server.Close()
+
+	go tunnel(localConnection, remoteConnection)
+
+	go func() {
+		request, _ := ioutil.ReadAll(server) //Until the client shuts down writing
+		server.Write(append([]byte("answer to "), request...))
+		server.Close()
+	}()
+
+	client.Write([]byte("request"))
+	client.CloseWrite()
+
+	answer, err := ioutil.ReadAll(client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(answer) != "answer to request" {
+		t.Errorf("Received %q; expected %q", answer, "answer to request")
+	}
+}
@@ -6,10 +6,15 @@ import (
 	"github.com/SnowRipple/crane/config"
 	"github.com/SnowRipple/crane/constants"
 	"github.com/SnowRipple/crane/container"
+	"github.com/SnowRipple/crane/exit"
+	"github.com/SnowRipple/crane/io"
+	ownLog "github.com/SnowRipple/crane/logger"
+	"github.com/SnowRipple/crane/output"
 	"github.com/SnowRipple/crane/utils"
 	flags "github.com/jessevdk/go-flags"
 	"github.com/mitchellh/cli"
 	"strings"
+	"time"
 )
 
 const (
@@ -27,7 +32,8 @@ func (c *FreezeCommand) Help() string {
 
   Usage: crane freeze <containerName1> <containerName2>
     
-Transforms (using docker lingo "commits") containers into immutable images using names defined in Cranefile (overwrites existing images). 
+Transforms (using docker lingo "commits") containers into snapshots: images named after the Cranefile image and tagged snap-<containerName>-1, snap-<containerName>-2, ...
+The Cranefile image is left untouched and every snapshot is recorded in the state file (see crane snapshots and crane rollback).
 
 Usage: crane freeze <containerName1>::<imageName1> <containerName2>::<imageName2>
     
@@ -40,7 +46,10 @@ Both options can be used within a single crane command.
 
 Available options:
 
--a (--all) : Freeze all containers defined in the Cranefile.Useful when you want to save all your work done on different containers.`
+-a (--all) : Freeze all containers defined in the Cranefile.Useful when you want to save all your work done on different containers.
+-u (--update) : Overwrites the images defined in the Cranefile instead of taking snapshots.
+-k (--keep-going) : Carries on freezing remaining containers when one of them fails.
+--fail-fast : Stops at the first container that failed to be frozen (default).`
 	return strings.TrimSpace(helpText)
 }
 
@@ -57,45 +66,123 @@ func (c *FreezeCommand) Run(containerNames []string) int {
 	containerNames, err := flags.ParseArgs(&options, containerNames)
 
 	if err != nil {
-		logger.Fatalf("Failed to parse freeze flags for following CLI arguments:\n%v", containerNames)
+		return exitWithError(exit.Errorf(exit.USAGE_ERROR, "Failed to parse freeze flags for following CLI arguments:\n%v", containerNames))
 	}
 
-	if options.All { //Freeze all containers defined in the Cranefile
-		for containerName, _ := range c.Config.CraneConfig.Containers {
-			containerNames = append(containerNames, containerName)
+	containerNames, err = c.chosenContainerNames(containerNames, options.All)
+	if err != nil {
+		return exitWithError(err)
+	}
+
+	containersBatch, err := newBatch(c.Ui, options)
+	if err != nil {
+		return exitWithError(err)
+	}
+
+	for _, chosenContainerName := range containerNames {
+		containersBatch.Run(chosenContainerName, func() error {
+			return c.freezeContainer(chosenContainerName, options.Update)
+		})
+	}
+
+	return containersBatch.Finish()
+}
+
+//Commits chosen containers into images stopping at the first failure.
+func (c *FreezeCommand) freeze(containerNames []string, overwrite bool) error {
+
+	for _, chosenContainerName := range containerNames {
+		if err := c.freezeContainer(chosenContainerName, overwrite); err != nil {
+			return err
 		}
+	}
+	return nil
+}
+
+//Returns containers chosen by the user or all containers defined in the Cranefile.
+func (c *FreezeCommand) chosenContainerNames(containerNames []string, all bool) ([]string, error) {
+
+	if all { //Freeze all containers defined in the Cranefile
+		containerNames = append(containerNames, sortedContainerNames(c.Config.CraneConfig.Containers)...)
 		logger.Debug("Commit all containers defined in theCranefile:\n%v", containerNames)
 	} else if len(containerNames) == 0 {
-		logger.Fatal("No arguments provided for the freeze command.Please correct.")
+		return nil, exit.Errorf(exit.USAGE_ERROR, "No arguments provided for the freeze command.Please correct.")
 	}
-	for _, chosenContainerName := range containerNames {
+	return containerNames, nil
+}
 
-		containerName, imageName := extractContainerImageNames(c.Config.CraneConfig.Containers, chosenContainerName)
-		containerState := utils.GetRequestedContainerState(c.Config.CraneState.StateContainers, containerName, true) //It must exist in the state file to be frozen
+//Commits a single container (<containerName> or <containerName>::<imageName>) into an image.
+//Containers without an image name become the next snapshot unless the Cranefile image should be overwritten.
+func (c *FreezeCommand) freezeContainer(chosenContainerName string, overwrite bool) error {
 
-		logger.Debug("Committing container %q into image %q", containerName, imageName)
+	containerName, imageName, err := extractContainerImageNames(c.Config.CraneConfig.Containers, chosenContainerName)
+	if err != nil {
+		return err
+	}
+	snapshot := !overwrite && !strings.Contains(chosenContainerName, constants.FREEZE_DELIMITER)
+	if snapshot {
+		imageName = container.NextSnapshotImage(imageName, containerName, c.Config.CraneState.Snapshots[containerName])
+	}
+	defer ownLog.ScopeField(ownLog.CONTAINER_FIELD, containerName)()
+	action := output.StartAction(output.FREEZE_ACTION, containerName)
+	action.Image = imageName
+	containerState, err := utils.GetRequestedContainerState(c.Config.CraneState.StateContainers, containerName, true) //It must exist in the state file to be frozen
+	if err != nil {
+		return err
+	}
+	host, err := utils.GetRequestedHost(c.Config.CraneConfig.Hosts, containerState.Host) //Commit on the daemon that owns the container
+	if err != nil {
+		return err
+	}
 
-		dockerCommand := []string{constants.DOCKER, constants.COMMIT, containerState.ID, imageName}
+	logger.Debug("Committing container %q into image %q", containerName, imageName)
 
-		outputBytes, err := executer.GetCommandOutput(dockerCommand)
-		if err != nil {
-			logger.Fatal("Error during \"freeze\" command:", utils.ExtractContainerMessage(outputBytes, err))
+	dockerCommand := host.DockerCommand(constants.COMMIT, containerState.ID, imageName)
+
+	outputBytes, err := executer.GetCommandOutput(dockerCommand)
+	if err != nil {
+		return exit.Errorf(exit.DOCKER_ERROR, "Error during \"freeze\" command:%s", utils.ExtractContainerMessage(outputBytes, err))
+	}
+
+	imageId := strings.TrimSpace(string(outputBytes))
+
+	action.ContainerID = containerState.ID
+	action.ImageID = imageId
+
+	if snapshot {
+		if err := c.recordSnapshot(containerName, container.Snapshot{Image: imageName, ID: imageId, Created: time.Now().UTC().Format(time.RFC3339)}); err != nil {
+			return err
 		}
+	}
+
+	action.Finish()
+	logger.Notice("Successfully froze container %q into image %q with id %q", containerName, imageName, imageId)
+	return nil
+}
 
-		imageId := strings.TrimSpace(string(outputBytes))
-		logger.Notice("Successfully froze container %q into image %q with id %q", containerName, imageName, imageId)
+//Appends a snapshot to the container's history in the state file.
+func (c *FreezeCommand) recordSnapshot(containerName string, snapshot container.Snapshot) error {
+
+	if c.Config.CraneState.Snapshots == nil {
+		c.Config.CraneState.Snapshots = map[string][]container.Snapshot{}
+	}
+	history := append(c.Config.CraneState.Snapshots[containerName], snapshot)
+
+	if err := io.UpdateSnapshots(containerName, history); err != nil {
+		return err
 	}
-	return 0
+	c.Config.CraneState.Snapshots[containerName] = history //Later freezes of this invocation continue the numbering
+	return nil
 }
 
 //Extracts the name of a container to be frozen and the new name of frozen container(image).
-func extractContainerImageNames(containers map[string]container.Container, chosenContainer string) (currentContainerName, imageName string) {
+func extractContainerImageNames(containers map[string]container.Container, chosenContainer string) (currentContainerName, imageName string, err error) {
 
 	if strings.Contains(chosenContainer, constants.FREEZE_DELIMITER) { //User specified image names
 		imageParameters := strings.Split(chosenContainer, constants.FREEZE_DELIMITER)
 
 		if len(imageParameters) != 2 {
-			logger.Fatalf("Invalid number of image parameters provided.Expected %d, Actual %d.Parameters are: \n%v", NUMBER_OF_PARAMS, len(imageParameters), imageParameters)
+			return "", "", exit.Errorf(exit.USAGE_ERROR, "Invalid number of image parameters provided.Expected %d, Actual %d.Parameters are: \n%v", NUMBER_OF_PARAMS, len(imageParameters), imageParameters)
 		}
 
 		currentContainerName = imageParameters[0]
@@ -105,11 +192,14 @@ func extractContainerImageNames(containers map[string]container.Container, chose
 
 		currentContainerName = chosenContainer
 
-		currentContainerConfig := utils.GetRequestedContainerConfig(containers, chosenContainer, true)
+		currentContainerConfig, err := utils.GetRequestedContainerConfig(containers, chosenContainer, true)
+		if err != nil {
+			return "", "", err
+		}
 
 		imageName = currentContainerConfig.Image
 	}
-	return currentContainerName, imageName
+	return currentContainerName, imageName, nil
 }
 
 func (c *FreezeCommand) Synopsis() string {
@@ -0,0 +1,162 @@
+package command
+
+import (
+	"fmt"
+	"github.com/SnowRipple/crane/config"
+	"github.com/SnowRipple/crane/container"
+	"github.com/SnowRipple/crane/exit"
+	"github.com/SnowRipple/crane/utils"
+	"strings"
+)
+
+//Checks host ports of containers about to be started before any of them is started so start does not fail halfway.
+//Reports every port claimed by two of these containers and every port already in use by a running container or another process.
+//Containers that are not started and not running claim nothing, so alternatives sharing a port can be started one at a time.
+func checkPortConflicts(cfg config.TomlConfig, containerNames []string) error {
+
+	starting := map[string]bool{}
+	for _, containerName := range containerNames {
+		starting[containerName] = true
+	}
+
+	var claims, flexibleClaims []container.HostPortClaim
+	for _, containerName := range sortedContainerNames(cfg.CraneConfig.Containers) {
+		if !starting[containerName] {
+			continue
+		}
+		containerConfig := cfg.CraneConfig.Containers[containerName]
+		for _, claim := range containerConfig.Ports.Claims(containerName, containerConfig.Host) {
+			if claim.IsFlexible() {
+				flexibleClaims = append(flexibleClaims, claim)
+			} else {
+				claims = append(claims, claim)
+			}
+		}
+	}
+
+	running := runningStateContainers(cfg, starting)
+
+	var conflicts []string
+
+	for index, claim := range claims {
+		//Ports claimed twice by started containers
+		for _, other := range claims[index+1:] {
+			if claim.Container != other.Container && claim.Conflicts(other) {
+				conflicts = append(conflicts, fmt.Sprintf("Host port %s is claimed by both container %q and container %q", claim, claim.Container, other.Container))
+			}
+		}
+
+		//Ports bound by running containers or other processes
+		user, inUse, err := portUser(cfg, claim, running)
+		if err != nil {
+			return err
+		}
+		if inUse {
+			conflicts = append(conflicts, fmt.Sprintf("Host port %s of container %q is already in use by %s", claim, claim.Container, user))
+		}
+	}
+
+	//Ranges published by a single port need one free port only, the ones picked so far are taken
+	for _, flexibleClaim := range flexibleClaims {
+		claim, found, err := freeHostPort(cfg, flexibleClaim, claims, running)
+		if err != nil {
+			return err
+		}
+		if !found {
+			conflicts = append(conflicts, fmt.Sprintf("No host port of %s is free for container %q", flexibleClaim, flexibleClaim.Container))
+			continue
+		}
+		claims = append(claims, claim)
+	}
+
+	if len(conflicts) > 0 {
+		return exit.Errorf(exit.CONFIG_ERROR, "Host port conflicts found, no containers were started:\n%s\nPlease correct PORTS or free the ports.", strings.Join(conflicts, "\n"))
+	}
+	return nil
+}
+
+//Returns containers of the state file that publish ports, are running and are not about to be started.
+func runningStateContainers(cfg config.TomlConfig, starting map[string]bool) map[string]container.StateContainer {
+
+	running := map[string]container.StateContainer{}
+	for _, containerName := range sortedStateContainerNames(cfg.CraneState.StateContainers) {
+
+		stateContainer := cfg.CraneState.StateContainers[containerName]
+		if starting[containerName] || len(stateContainer.Ports) == 0 {
+			continue
+		}
+		host, err := utils.GetRequestedHost(cfg.CraneConfig.Hosts, stateContainer.Host)
+		if err != nil {
+			logger.Debug("Ports of container %q are not checked: %v", containerName, err)
+			continue
+		}
+		if getContainerState(host, stateContainer.ID) == STATE_RUNNING {
+			running[containerName] = stateContainer
+		}
+	}
+	return running
+}
+
+//Names who uses a host port already: a running container or, for the local daemon, a process of this machine.
+func portUser(cfg config.TomlConfig, claim container.HostPortClaim, running map[string]container.StateContainer) (string, bool, error) {
+
+	for _, containerName := range sortedStateContainerNames(running) {
+		if running[containerName].Publishes(claim) {
+			return fmt.Sprintf("container %q", containerName), true, nil
+		}
+	}
+
+	host, err := utils.GetRequestedHost(cfg.CraneConfig.Hosts, claim.Host)
+	if err != nil {
+		return "", false, err
+	}
+	if !host.IsLocal() { //Ports of remote hosts can't be probed from here, the daemon reports them
+		return "", false, nil
+	}
+	if claim.IsBound() {
+		return portOwner(cfg, claim), true, nil
+	}
+	return "", false, nil
+}
+
+//Picks the port of a flexible claim: the first port of its range that is neither claimed nor in use.
+func freeHostPort(cfg config.TomlConfig, flexibleClaim container.HostPortClaim, claims []container.HostPortClaim, running map[string]container.StateContainer) (container.HostPortClaim, bool, error) {
+
+	for hostPort := flexibleClaim.HostPort; hostPort <= flexibleClaim.LastHostPort; hostPort++ {
+
+		claim := flexibleClaim.At(hostPort)
+		if isClaimed(claim, claims) {
+			continue
+		}
+		_, inUse, err := portUser(cfg, claim, running)
+		if err != nil {
+			return claim, false, err
+		}
+		if !inUse {
+			return claim, true, nil
+		}
+	}
+	return flexibleClaim, false, nil
+}
+
+//Checks if a host port is claimed already.
+func isClaimed(claim container.HostPortClaim, claims []container.HostPortClaim) bool {
+
+	for _, other := range claims {
+		if claim.Conflicts(other) {
+			return true
+		}
+	}
+	return false
+}
+
+//Names the owner of a bound port: a container recorded in the state file or another process.
+func portOwner(cfg config.TomlConfig, claim container.HostPortClaim) string {
+
+	for _, containerName := range sortedStateContainerNames(cfg.CraneState.StateContainers) {
+		if cfg.CraneState.StateContainers[containerName].Publishes(claim) {
+			return fmt.Sprintf("container %q", containerName)
+		}
+	}
+	return "another process"
+}
@@ -0,0 +1,87 @@
+package command
+
+import (
+	"github.com/SnowRipple/crane/config"
+	"github.com/SnowRipple/crane/container"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestCheckPortConflicts(t *testing.T) {
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	boundPort := strconv.Itoa(listener.Addr().(*net.TCPAddr).Port)
+
+	parsePort := func(specification string) container.Port {
+		port, err := container.ParsePort(specification)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return port
+	}
+
+	cfg := config.TomlConfig{}
+	cfg.CraneConfig.Containers = map[string]container.Container{
+		"web":   {Ports: container.Ports{parsePort("127.0.0.1:" + boundPort + ":80")}},
+		"api":   {Ports: container.Ports{parsePort("127.0.0.1:" + boundPort + ":8080")}},
+		"cache": {Ports: container.Ports{parsePort("6379")}},
+	}
+
+	if err := checkPortConflicts(cfg, []string{"cache"}); err != nil {
+		t.Errorf("Unexpected conflict: %v", err)
+	}
+
+	err = checkPortConflicts(cfg, []string{"web"})
+	if err == nil {
+		t.Fatalf("Conflicts not detected")
+	}
+	if !strings.Contains(err.Error(), `of container "web" is already in use by another process`) {
+		t.Errorf("%q does not report the bound port", err)
+	}
+	if strings.Contains(err.Error(), "claimed by both") {
+		t.Errorf("Container %q is not started, it claims nothing: %v", "api", err)
+	}
+
+	err = checkPortConflicts(cfg, []string{"api", "web"})
+	if err == nil || !strings.Contains(err.Error(), `claimed by both container "api" and container "web"`) {
+		t.Errorf("Port claimed twice not reported: %v", err)
+	}
+}
+
+func TestCheckFlexiblePortConflicts(t *testing.T) {
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	boundPort := listener.Addr().(*net.TCPAddr).Port
+
+	nextListener, err := net.Listen("tcp", "127.0.0.1:"+strconv.Itoa(boundPort+1))
+	if err != nil {
+		t.Skipf("Port next to %d is not free: %v", boundPort, err)
+	}
+
+	portRange := "127.0.0.1:" + strconv.Itoa(boundPort) + "-" + strconv.Itoa(boundPort+1) + ":80"
+	port, err := container.ParsePort(portRange)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := config.TomlConfig{}
+	cfg.CraneConfig.Containers = map[string]container.Container{"web": {Ports: container.Ports{port}}}
+
+	if err := checkPortConflicts(cfg, []string{"web"}); err == nil || !strings.Contains(err.Error(), "No host port of") {
+		t.Errorf("Range without a free port not reported: %v", err)
+	}
+
+	nextListener.Close()
+	if err := checkPortConflicts(cfg, []string{"web"}); err != nil {
+		t.Errorf("A free port of the range is enough: %v", err)
+	}
+}
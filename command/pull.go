@@ -5,7 +5,9 @@ import (
 	"github.com/SnowRipple/crane/command/executer"
 	"github.com/SnowRipple/crane/constants"
 	"github.com/SnowRipple/crane/container"
+	"github.com/SnowRipple/crane/exit"
 	ownLog "github.com/SnowRipple/crane/logger"
+	"github.com/SnowRipple/crane/output"
 	"github.com/SnowRipple/crane/utils"
 	flags "github.com/jessevdk/go-flags"
 	"github.com/mitchellh/cli"
@@ -44,7 +46,7 @@ func (c *PullCommand) Run(commandArguments []string) int {
 	cmdFlags.Usage = func() { c.Ui.Output(c.Help()) }
 
 	if len(commandArguments) == 0 {
-		logger.Fatalf("Not enough arguments provided for the pull command.Please correct.")
+		return exitWithError(exit.Errorf(exit.USAGE_ERROR, "Not enough arguments provided for the pull command.Please correct."))
 	}
 
 	images, err := flags.ParseArgs(&options, commandArguments)
@@ -52,7 +54,7 @@ func (c *PullCommand) Run(commandArguments []string) int {
 	logger.Debug("Provided images are:%v", images)
 
 	if err != nil {
-		logger.Fatalf("Failed to parse pull flags for following CLI arguments:\n%v", commandArguments)
+		return exitWithError(exit.Errorf(exit.USAGE_ERROR, "Failed to parse pull flags for following CLI arguments:\n%v", commandArguments))
 	}
 
 	if options.All {
@@ -65,14 +67,17 @@ func (c *PullCommand) Run(commandArguments []string) int {
 
 	for _, imageName := range images {
 		logger.Debug("Pulling image %q", imageName)
+		action := output.StartAction(output.PULL_ACTION, "")
+		action.Image = imageName
 
 		dockerCommand := []string{constants.DOCKER, constants.PULL, imageName}
 
 		outputBytes, err := executer.GetCommandOutput(dockerCommand)
 		if err != nil {
-			logger.Fatal("Error during \"pull\" command:", utils.ExtractContainerMessage(outputBytes, err))
+			return exitWithError(exit.Errorf(exit.DOCKER_ERROR, "Error during \"pull\" command:%s", utils.ExtractContainerMessage(outputBytes, err)))
 		}
 		utils.PrintCommandOutput(outputBytes)
+		action.Finish()
 	}
 
 	return 0
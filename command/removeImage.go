@@ -5,6 +5,7 @@ import (
 	"github.com/SnowRipple/crane/command/executer"
 	"github.com/SnowRipple/crane/constants"
 	"github.com/SnowRipple/crane/container"
+	"github.com/SnowRipple/crane/exit"
 	"github.com/SnowRipple/crane/utils"
 	flags "github.com/jessevdk/go-flags"
 	"github.com/mitchellh/cli"
@@ -45,7 +46,7 @@ func (c *RemoveImageCommand) Run(imageNames []string) int {
 	imageNames, err := flags.ParseArgs(&options, imageNames)
 
 	if err != nil {
-		logger.Fatalf("Failed to parse rmi flags for following CLI arguments:\n%v", imageNames)
+		return exitWithError(exit.Errorf(exit.USAGE_ERROR, "Failed to parse rmi flags for following CLI arguments:\n%v", imageNames))
 	}
 
 	if options.All { //Remove all images
@@ -53,7 +54,7 @@ func (c *RemoveImageCommand) Run(imageNames []string) int {
 			imageNames = append(imageNames, container.Image)
 		}
 	} else if len(imageNames) == 0 {
-		logger.Fatalf("No arguments detected in the rmi command.Please correct.")
+		return exitWithError(exit.Errorf(exit.USAGE_ERROR, "No arguments detected in the rmi command.Please correct."))
 	}
 
 	dockerCommand := []string{constants.DOCKER, constants.REMOVE_IMAGE}
@@ -61,7 +62,7 @@ func (c *RemoveImageCommand) Run(imageNames []string) int {
 
 	removedImagesBytes, err := executer.GetCommandOutput(dockerCommand)
 	if err != nil {
-		logger.Fatal("Error when trying to remove images:\n%v\nError message:\n%v", imageNames, utils.ExtractContainerMessage(removedImagesBytes, err))
+		return exitWithError(exit.Errorf(exit.DOCKER_ERROR, "Error when trying to remove images:\n%v\nError message:\n%v", imageNames, utils.ExtractContainerMessage(removedImagesBytes, err)))
 	}
 
 	utils.PrintCommandOutput(removedImagesBytes)
@@ -0,0 +1,157 @@
+package command
+
+import (
+	"flag"
+	"github.com/SnowRipple/crane/command/executer"
+	"github.com/SnowRipple/crane/config"
+	"github.com/SnowRipple/crane/constants"
+	"github.com/SnowRipple/crane/container"
+	"github.com/SnowRipple/crane/exit"
+	"github.com/SnowRipple/crane/io"
+	ownLog "github.com/SnowRipple/crane/logger"
+	"github.com/SnowRipple/crane/output"
+	"github.com/SnowRipple/crane/utils"
+	flags "github.com/jessevdk/go-flags"
+	"github.com/mitchellh/cli"
+	"strings"
+)
+
+// RollbackCommand restarts a daemonized container from one of its snapshots.
+type RollbackCommand struct {
+	Ui     cli.Ui
+	Config config.TomlConfig
+}
+
+func (c *RollbackCommand) Help() string {
+	helpText := `
+  Usage: crane rollback <containerName>
+
+  Restarts a daemonized container from its previous snapshot: the one before the snapshot it runs from,
+  or the latest snapshot when it runs from the Cranefile image.The current container is removed.
+
+  Usage: crane rollback <containerName> <snapshot>
+
+  Restarts a daemonized container from a chosen snapshot, e.g. snap-<containerName>-2 (see crane snapshots).
+
+  Run crane start <containerName> to go back to the Cranefile image.
+  `
+	return strings.TrimSpace(helpText)
+}
+
+//Restarts a container from a snapshot.
+func (c *RollbackCommand) Run(arguments []string) int {
+
+	var options constants.CommonFlags
+
+	logger.Debug("Entered rollback command...")
+
+	cmdFlags := flag.NewFlagSet("rollback", flag.ContinueOnError)
+	cmdFlags.Usage = func() { c.Ui.Output(c.Help()) }
+
+	arguments, err := flags.ParseArgs(&options, arguments)
+	if err != nil {
+		return exitWithError(exit.Errorf(exit.USAGE_ERROR, "Failed to parse rollback options due to error:%v", err))
+	}
+	if len(arguments) < 1 || len(arguments) > 2 {
+		return exitWithError(exit.Errorf(exit.USAGE_ERROR, "The rollback command expects a container name and optionally a snapshot.Please correct."))
+	}
+
+	containerName := arguments[0]
+	defer ownLog.ScopeField(ownLog.CONTAINER_FIELD, containerName)()
+
+	var snapshotName string
+	if len(arguments) == 2 {
+		snapshotName = arguments[1]
+	}
+
+	if err := c.rollback(containerName, snapshotName, options); err != nil {
+		return exitWithError(err)
+	}
+	return 0
+}
+
+//Replaces a running container with a new one started from a snapshot.
+func (c *RollbackCommand) rollback(containerName, snapshotName string, options constants.CommonFlags) error {
+
+	containerConfig, containerState, err := utils.GetContainerConfigAndState(c.Config, containerName, true, false) //A removed container is simply started from the snapshot
+	if err != nil {
+		return err
+	}
+	if !containerConfig.Daemonized {
+		return exit.Errorf(exit.CONFIG_ERROR, "Container %q is not daemonized, only daemonized containers can be rolled back.Please use freeze with ::<imageName> and point IMAGE at the snapshot instead.", containerName)
+	}
+
+	snapshot, err := chooseSnapshot(c.Config.CraneState.Snapshots[containerName], containerName, snapshotName, containerState.Snapshot)
+	if err != nil {
+		return err
+	}
+
+	action := output.StartAction(output.ROLLBACK_ACTION, containerName)
+	action.Image = snapshot.Image
+	action.ImageID = snapshot.ID
+
+	if len(containerState.ID) > 0 {
+		host, err := utils.GetRequestedHost(c.Config.CraneConfig.Hosts, containerState.Host)
+		if err != nil {
+			return err
+		}
+		logger.Notice("Removing container %q...", containerName)
+		if outputBytes, err := executer.GetCommandOutput(host.DockerCommand(constants.REMOVE, constants.FORCE, containerState.ID)); err != nil {
+			logger.Debug("Failed to remove container %q, it is probably gone already:%s", containerName, utils.ExtractContainerMessage(outputBytes, err))
+		}
+	}
+
+	logger.Notice("Starting container %q from snapshot %q...", containerName, snapshot.Image)
+	startCommand := StartCommand{Ui: c.Ui, Config: c.Config}
+	newState, err := startCommand.startContainer(containerName, snapshotConfig(containerConfig, snapshot.Image), options)
+
+	if len(newState.ID) > 0 { //Record the container even if it failed later so it can be destroyed
+		newState.Snapshot = snapshot.Image
+		if stateErr := io.UpdateStateFile(map[string]container.StateContainer{containerName: newState}); stateErr != nil && err == nil {
+			err = stateErr
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	action.ContainerID = newState.ID
+	action.Finish()
+	logger.Notice("Container %q rolled back to snapshot %q", containerName, snapshot.Tag())
+	return nil
+}
+
+//Chooses the snapshot to roll back to: a given one or the one before the snapshot the container runs from.
+func chooseSnapshot(history []container.Snapshot, containerName, snapshotName, currentSnapshot string) (container.Snapshot, error) {
+
+	if len(history) == 0 {
+		return container.Snapshot{}, exit.Errorf(exit.CONFIG_ERROR, "Container %q has no snapshots, use crane freeze %s to take one.", containerName, containerName)
+	}
+
+	if len(snapshotName) > 0 {
+		snapshot, exists := container.FindSnapshot(history, snapshotName)
+		if !exists {
+			return snapshot, exit.Errorf(exit.USAGE_ERROR, "Container %q has no snapshot %q.Please choose one listed by crane snapshots %s.", containerName, snapshotName, containerName)
+		}
+		return snapshot, nil
+	}
+
+	snapshot, exists := container.PreviousSnapshot(history, currentSnapshot)
+	if !exists {
+		return snapshot, exit.Errorf(exit.CONFIG_ERROR, "Container %q already runs from its oldest snapshot %q.", containerName, currentSnapshot)
+	}
+	return snapshot, nil
+}
+
+//Returns the container settings running a snapshot's image instead of the Cranefile image.
+//Snapshots exist on the host only so they are never pulled nor built.
+func snapshotConfig(containerConfig container.Container, snapshotImage string) container.Container {
+
+	containerConfig.Image = snapshotImage
+	containerConfig.PullPolicy = container.PULL_NEVER
+	return containerConfig
+}
+
+func (c *RollbackCommand) Synopsis() string {
+	return "Restart a container from a previous snapshot."
+}
@@ -6,12 +6,14 @@ import (
 	"github.com/SnowRipple/crane/config"
 	"github.com/SnowRipple/crane/constants"
 	"github.com/SnowRipple/crane/container"
+	"github.com/SnowRipple/crane/exit"
 	"github.com/SnowRipple/crane/io"
+	ownLog "github.com/SnowRipple/crane/logger"
+	"github.com/SnowRipple/crane/output"
 	"github.com/SnowRipple/crane/ssh"
 	"github.com/SnowRipple/crane/utils"
 	flags "github.com/jessevdk/go-flags"
 	"github.com/mitchellh/cli"
-	"strconv"
 	"strings"
 )
 
@@ -53,7 +55,7 @@ func (c *RunCommand) Run(commandArguments []string) int {
 
 	commandArguments, err := flags.ParseArgs(&options, commandArguments)
 	if err != nil {
-		logger.Fatalf("Failed to parse runall flags for following CLI arguments:\n%v", commandArguments)
+		return exitWithError(exit.Errorf(exit.USAGE_ERROR, "Failed to parse run flags for following CLI arguments:\n%v", commandArguments))
 	}
 
 	logger.Debug("Entered run command...")
@@ -62,7 +64,7 @@ func (c *RunCommand) Run(commandArguments []string) int {
 	cmdFlags.Usage = func() { c.Ui.Output(c.Help()) }
 
 	if len(commandArguments) == 0 { //only RUN
-		logger.Fatal("Missing arguments.Please specify containers and corrresponding commands or did you mean runall?")
+		return exitWithError(exit.Errorf(exit.USAGE_ERROR, "Missing arguments.Please specify containers and corrresponding commands or did you mean runall?"))
 	}
 
 	//Freeze containers if required.
@@ -71,39 +73,65 @@ func (c *RunCommand) Run(commandArguments []string) int {
 		logger.Debug("Save option detected.Following new images will be created:\n%v", imageQueue)
 	}
 
+	interactive := len(commandArguments) == 1 //Commands run one after another would compete for the terminal input
+
+	defer ownLog.RemoveField(ownLog.CONTAINER_FIELD) //The last container must not stick to later records
 	for index, argument := range commandArguments {
 
 		//Extract the container name
 		logger.Debug("\nThe %d argument is %s\n", index, argument)
 		arguments := strings.Split(argument, constants.COMMANDS_DELIMITER)
 		if len(arguments) != 2 {
-			logger.Fatal("Wrong arguments format.Please correct.")
+			return exitWithError(exit.Errorf(exit.USAGE_ERROR, "Wrong arguments format.Please correct."))
 		}
 
 		chosenContainerName := arguments[0]
+		ownLog.SetField(ownLog.CONTAINER_FIELD, chosenContainerName)
 		enteredCommands := arguments[1]
 
 		//Get requested container configuration
-		requestedContainerConfig, requestedContainerState := utils.GetContainerConfigAndState(c.Config, chosenContainerName, true, false) //It must be in the config but not necessarily in the state(for new not daemonized containers)
-		command := buildContainerCommand(requestedContainerConfig, chosenContainerName, enteredCommands)
+		requestedContainerConfig, requestedContainerState, err := utils.GetContainerConfigAndState(c.Config, chosenContainerName, true, false) //It must be in the config but not necessarily in the state(for new not daemonized containers)
+		if err != nil {
+			return exitWithError(err)
+		}
 
-		runCommandInContainer(c.Ui, requestedContainerConfig, requestedContainerState, chosenContainerName, command, options.ForceImage)
+		command, err := buildContainerCommand(requestedContainerConfig, chosenContainerName, enteredCommands)
+		if err != nil {
+			return exitWithError(err)
+		}
+
+		if err := runCommandInContainer(c.Ui, c.Config.CraneConfig.Hosts, requestedContainerConfig, requestedContainerState, chosenContainerName, command, options, interactive); err != nil {
+			return exitWithError(err)
+		}
 
 		//Freeze container into image if requested (requires updated state file)
 		if options.Update {
 			logger.Debug("Overwriting existing image for container %q...", chosenContainerName)
-			freezeCommand := FreezeCommand{Ui: c.Ui, Config: config.ReadConfig()}
-			freezeCommand.Run([]string{chosenContainerName})
+			err = freezeWithCurrentState(c.Ui, []string{chosenContainerName})
 		} else if imageQueue.Length() > 0 {
 			newImageName := imageQueue.Pop().Value
-			freezeCommand := FreezeCommand{Ui: c.Ui, Config: config.ReadConfig()}
 			logger.Debug("Existing container %q will be committed as an image %q", chosenContainerName, newImageName)
-			freezeCommand.Run([]string{chosenContainerName + constants.FREEZE_DELIMITER + newImageName})
+			err = freezeWithCurrentState(c.Ui, []string{chosenContainerName + constants.FREEZE_DELIMITER + newImageName})
+		}
+		if err != nil {
+			return exitWithError(err)
 		}
 	}
 	return 0
 }
 
+//Freezes containers using the state file updated by the commands that were just run.
+func freezeWithCurrentState(ui cli.Ui, containerNames []string) error {
+
+	currentConfig, err := config.ReadConfig()
+	if err != nil {
+		return err
+	}
+
+	freezeCommand := FreezeCommand{Ui: ui, Config: currentConfig}
+	return freezeCommand.freeze(containerNames, true)
+}
+
 //Extracts image names for images build from running containers.
 
 func extractNewImageNames(newImagesNames string) *utils.Queue {
@@ -141,37 +169,37 @@ func extractCLCommands(initialCommand string) []string {
 }
 
 //Build a command that will be executed inside a container.
-func buildContainerCommand(requestedContainerConfig container.Container, requestedContainerName, initialCommand string) string {
+func buildContainerCommand(requestedContainerConfig container.Container, requestedContainerName, initialCommand string) (string, error) {
 
 	//Extract commands
 	if strings.Index(initialCommand, constants.OWN_COMMANDS_DELIMITER) == 0 { //own commands
 		logger.Debug("User's own commands detected.")
-		return strings.TrimLeft(initialCommand, constants.OWN_COMMANDS_DELIMITER)
+		return strings.TrimLeft(initialCommand, constants.OWN_COMMANDS_DELIMITER), nil
 	} else { //Cranefile commands
 		logger.Debug("Cranefile commands detected.")
 
 		containerCommands := requestedContainerConfig.Commands
 		if len(containerCommands) == 0 {
-			logger.Fatalf("Either the container %q does not exist in the Cranefile or it has not any commands defined in the Cranefile.Please correct.", requestedContainerName)
+			return "", exit.Errorf(exit.CONFIG_ERROR, "Either the container %q does not exist in the Cranefile or it has not any commands defined in the Cranefile.Please correct.", requestedContainerName)
 		}
 		craneCommands := extractCLCommands(initialCommand)
 
 		return buildCommandList(craneCommands, containerCommands)
 	}
 
-	return "Unreachable reached...End of the world approaching..." //This is unreachable but go compiler requires it...
+	return "Unreachable reached...End of the world approaching...", nil //This is unreachable but go compiler requires it...
 
 }
 
 //Builds a list of commands to be executed per a container. Commands come from the Cranefile.
-func buildCommandList(craneCommands []string, containerCommands [][]string) string {
+func buildCommandList(craneCommands []string, containerCommands [][]string) (string, error) {
 
 	var commandList string
 
 	for _, craneCommand := range craneCommands {
 		for secondIndex, cranefileCommandPair := range containerCommands {
 			if len(cranefileCommandPair) != COMMANDS_ARGUMENT_COUNT {
-				logger.Fatal("Wrong amount of command  arguments specified for the pair nr " + strconv.Itoa(secondIndex) + ": Expected " + strconv.Itoa(COMMANDS_ARGUMENT_COUNT) + ", Actual " + strconv.Itoa(len(cranefileCommandPair)) + ". Please correct(if you need to specify multiple commands please use \"<command1>;<command2>\" format.")
+				return "", wrongCommandPairError(secondIndex, cranefileCommandPair)
 			}
 			if craneCommand == cranefileCommandPair[0] { //Found chosen command
 				if len(commandList) > 1 {
@@ -184,42 +212,100 @@ func buildCommandList(craneCommands []string, containerCommands [][]string) stri
 		}
 	}
 
-	return commandList
+	return commandList, nil
+}
+
+//Reports a Cranefile command pair with a wrong amount of arguments.
+func wrongCommandPairError(index int, cranefileCommandPair []string) error {
+	return exit.Errorf(exit.CONFIG_ERROR, "Wrong amount of command  arguments specified for the pair nr %d: Expected %d, Actual %d. Please correct(if you need to specify multiple commands please use \"<command1>;<command2>\" format.", index, COMMANDS_ARGUMENT_COUNT, len(cranefileCommandPair))
 }
 
 //Run a specified command in a specified container.Updates the state file.
-func runCommandInContainer(ui cli.Ui, containerConfig container.Container, containerState container.StateContainer, containerName, command string, useHostImage bool) {
+//Non-zero exit status of the command is returned as an error carrying the same exit code.
+//The command is terminated when it does not finish within the --command-timeout.
+//Only interactive commands of daemonized containers read the local stdin.
+func runCommandInContainer(ui cli.Ui, hosts map[string]container.Host, containerConfig container.Container, containerState container.StateContainer, containerName, command string, options constants.CommonFlags, interactive bool) error {
+
+	action := output.StartAction(output.RUN_ACTION, containerName)
+	action.Image = containerConfig.Image
 
 	if containerConfig.Daemonized {
-		ssh.SshConnect(containerState.IP, containerConfig.Username, containerConfig.Password, constants.SHELL_COMMAND+" "+constants.SHELL_STRING_OPTION+" \""+command+"\"")
-	} else { //Not daemonized
+		action.ContainerID = containerState.ID
+		action.IP = containerState.IP
 
-		dockerCommand := container.BuildRunCommand(containerConfig, containerName, false, true) //Needs cidfile to store id
+		stateHost, err := utils.GetRequestedHost(hosts, containerState.Host)
+		if err != nil {
+			return finishRunAction(action, err)
+		}
+		err = ssh.SshConnectWithTimeout(containerState.SshAddress(stateHost), containerConfig.Username, containerConfig.Password, constants.SHELL_COMMAND+" "+constants.SHELL_STRING_OPTION+" \""+command+"\"", options.CommandTimeout, containerConfig.Graphical, interactive)
+		return finishRunAction(action, err)
+	}
 
-		if !useHostImage {
-			buildImageCommand := BuildImageCommand{Ui: ui}
-			buildImageCommand.BuildImageIfNeeded(containerConfig)
+	//Not daemonized
+	host, err := utils.GetRequestedHost(hosts, containerConfig.Host)
+	if err != nil {
+		return finishRunAction(action, err)
+	}
+
+	dockerCommand, err := container.BuildRunCommand(host, containerConfig, containerName, false, true) //Needs cidfile to store id
+	if err != nil {
+		return finishRunAction(action, err)
+	}
 
-		} else {
-			logger.Debug("Force option detected, will use host system image.")
+	if !options.ForceImage {
+		buildImageCommand := BuildImageCommand{Ui: ui}
+		if err := buildImageCommand.PrepareImage(host, containerName, containerConfig); err != nil {
+			return finishRunAction(action, err)
 		}
-		//Append "/bin/bash -c" and command
-		dockerCommand = append(dockerCommand, constants.SHELL_COMMAND)
-		dockerCommand = append(dockerCommand, constants.SHELL_STRING_OPTION)
-		dockerCommand = append(dockerCommand, command)
+	} else {
+		logger.Debug("Force option detected, will use host system image.")
+	}
 
-		outputBytes, err := executer.GetCommandOutput(dockerCommand)
-		if err != nil {
-			logger.Fatal("Error during \"run\" command with non daemonized container:", utils.ExtractContainerMessage(outputBytes, err))
+	if err := createNamedVolumes(host, containerName, containerConfig); err != nil {
+		return finishRunAction(action, err)
+	}
+	//Append "/bin/bash -c" and command
+	dockerCommand = append(dockerCommand, constants.SHELL_COMMAND)
+	dockerCommand = append(dockerCommand, constants.SHELL_STRING_OPTION)
+	dockerCommand = append(dockerCommand, command)
+
+	outputBytes, runErr := executer.GetCommandOutputWithTimeout(dockerCommand, options.CommandTimeout)
+	utils.PrintCommandOutput(outputBytes)
+
+	if runErr != nil {
+		statusErr := executer.ExitStatusError(runErr)
+		runErr = exit.Errorf(exit.Code(statusErr), "Error during \"run\" command with non daemonized container %q: %v", containerName, statusErr)
+	}
+
+	//The container exists even if the command failed so it is recorded anyway
+	id, err := io.GetContainerIdFromFile(containerName)
+	if err != nil {
+		if runErr != nil {
+			return finishRunAction(action, runErr)
 		}
+		return finishRunAction(action, err)
+	}
+	action.ContainerID = id
+	trackCreatedContainer(containerName, id, host)
 
-		utils.PrintCommandOutput(outputBytes)
+	//Update the state file
+	if err := io.UpdateStateFile(map[string]container.StateContainer{containerName: {ID: id, IP: constants.NOT_DAEMONIZED_IP, Host: containerConfig.Host}}); err != nil { //non-daemonized have no ip
+		return finishRunAction(action, err)
+	}
+
+	return finishRunAction(action, runErr)
+}
 
-		id := io.GetContainerIdFromFile(containerName)
+//Records the outcome of a run action.
+func finishRunAction(action *output.Action, err error) error {
 
-		//Update the state file
-		io.UpdateStateFile(map[string]container.StateContainer{containerName: {ID: id, IP: constants.NOT_DAEMONIZED_IP}}) //non-daemonized have no ip
+	if err != nil {
+		action.Fail(exit.Code(err), err.Error())
+		return err
 	}
+
+	action.Finish()
+	return nil
 }
 
 func (c *RunCommand) Synopsis() string {
@@ -5,10 +5,10 @@ import (
 	"github.com/SnowRipple/crane/config"
 	"github.com/SnowRipple/crane/constants"
 	"github.com/SnowRipple/crane/container"
+	"github.com/SnowRipple/crane/exit"
 	"github.com/SnowRipple/crane/utils"
 	flags "github.com/jessevdk/go-flags"
 	"github.com/mitchellh/cli"
-	"strconv"
 	"strings"
 )
 
@@ -41,6 +41,10 @@ func (c *RunallCommand) Help() string {
 
   Transforms(commits) all containers into immutable images that will replace existing images.
   
+  crane runall -k (--keep-going)
+
+  Carries on with remaining containers when one of them fails.By default (--fail-fast) runall stops at the first failing container.
+  
   `
 	return strings.TrimSpace(helpText)
 }
@@ -48,10 +52,8 @@ func (c *RunallCommand) Help() string {
 //Execute all commands defined in the Cranefile.
 func (c *RunallCommand) Run(arguments []string) int {
 
-	var (
-		options       constants.CommonFlags
-		freezeCommand = FreezeCommand{Ui: c.Ui, Config: c.Config}
-	)
+	var options constants.CommonFlags
+
 	logger.Debug("Entered runall command..")
 
 	cmdFlags := flag.NewFlagSet("runall", flag.ContinueOnError)
@@ -59,7 +61,7 @@ func (c *RunallCommand) Run(arguments []string) int {
 
 	arguments, err := flags.ParseArgs(&options, arguments)
 	if err != nil {
-		logger.Fatalf("Failed to parse runall options due to error:", err)
+		return exitWithError(exit.Errorf(exit.USAGE_ERROR, "Failed to parse runall options due to error:%v", err))
 	}
 
 	allContainersConfig := c.Config.CraneConfig.Containers
@@ -69,7 +71,9 @@ func (c *RunallCommand) Run(arguments []string) int {
 	runContainer := options.RunAllContainer
 
 	//Only one runall option can be specified in a command.If you need more use scripts
-	checkRunAllOptionsValidity([]string{runCommand, runOwnCommand, runContainer})
+	if err := checkRunAllOptionsValidity([]string{runCommand, runOwnCommand, runContainer}); err != nil {
+		return exitWithError(err)
+	}
 
 	//Get a list of containers to be run if required
 	if len(runContainer) > 0 {
@@ -77,37 +81,56 @@ func (c *RunallCommand) Run(arguments []string) int {
 		logger.Debug("Containers specified by the user are:\n %v", allContainersConfig)
 	}
 
-	for containerName, containerConfig := range allContainersConfig {
+	containersBatch, err := newBatch(c.Ui, options)
+	if err != nil {
+		return exitWithError(err)
+	}
 
-		command := buildCommand(runCommand, runOwnCommand, containerConfig.Commands)
+	for _, containerName := range sortedContainerNames(allContainersConfig) {
 
-		containerState := utils.GetRequestedContainerState(allContainersState, containerName, false)
+		containerConfig := allContainersConfig[containerName]
 
-		runCommandInContainer(c.Ui, containerConfig, containerState, containerName, command, options.ForceImage)
+		containersBatch.Run(containerName, func() error {
 
-		if options.Update { //Update images if requested
-			logger.Debug("Overwriting existing image for container %q...", containerName)
-			freezeCommand.Run([]string{containerName})
-		}
+			command, err := buildCommand(runCommand, runOwnCommand, containerConfig.Commands)
+			if err != nil {
+				return err
+			}
+
+			containerState, _ := utils.GetRequestedContainerState(allContainersState, containerName, false)
+
+			if err := runCommandInContainer(c.Ui, c.Config.CraneConfig.Hosts, containerConfig, containerState, containerName, command, options, false); err != nil {
+				return err
+			}
+
+			if options.Update { //Update images if requested
+				logger.Debug("Overwriting existing image for container %q...", containerName)
+				return freezeWithCurrentState(c.Ui, []string{containerName})
+			}
+			return nil
+		})
 	}
 
-	return 0
+	return containersBatch.Finish()
 }
 
 //Builds an overall command that will be run in a single container
-func buildCommand(runCommand, runOwnCommand string, cranefileCommands [][]string) string {
+func buildCommand(runCommand, runOwnCommand string, cranefileCommands [][]string) (string, error) {
 
-	var command string
+	var (
+		command string
+		err     error
+	)
 	if len(runCommand) > 0 { //Run specified Cranefile commands across all containers
-		command = extractCranefileCommands(cranefileCommands, runCommand)
+		command, err = extractCranefileCommands(cranefileCommands, runCommand)
 	} else if len(runOwnCommand) > 0 { //Run own commands across all containers
 		command = runOwnCommand
 	} else { //Run all Cranefile commands
-		command = extractCranefileCommands(cranefileCommands, "") //Empty string so all commands will be used
+		command, err = extractCranefileCommands(cranefileCommands, "") //Empty string so all commands will be used
 	}
 
 	logger.Debug("Final command is %q", command)
-	return command
+	return command, err
 }
 
 //Returns containers that were specified by the user.
@@ -129,11 +152,12 @@ func extractChosenContainers(allContainers map[string]container.Container, runCo
 
 //Extract commands specified by the user from the Cranefile.
 //If userChosenCraneCommands are empty, all commands will be returned.
-func extractCranefileCommands(commands [][]string, userChosenCraneCommands string) string {
+func extractCranefileCommands(commands [][]string, userChosenCraneCommands string) (string, error) {
 
 	var (
 		command           string
 		userCraneCommands []string
+		err               error
 	)
 
 	if len(userChosenCraneCommands) > 0 { //Use commands specified by the user only
@@ -141,31 +165,33 @@ func extractCranefileCommands(commands [][]string, userChosenCraneCommands strin
 		userCraneCommands = strings.Split(userChosenCraneCommands, constants.INTERNAL_DELIMITER)
 
 		for _, userCraneCommand := range userCraneCommands {
-			command = appendRunAllCommand(commands, command, userCraneCommand)
+			if command, err = appendRunAllCommand(commands, command, userCraneCommand); err != nil {
+				return "", err
+			}
 		}
 	} else { //No user commands specified so grab all commands
 
-		command = appendRunAllCommand(commands, command, "") //Empty so all cranefile commands will be used
+		command, err = appendRunAllCommand(commands, command, "") //Empty so all cranefile commands will be used
 	}
 
-	return command
+	return command, err
 }
 
 //Finds and appends a cranefile command to a list of commands to be executed.
-func appendRunAllCommand(commands [][]string, commandList, userCraneCommand string) string {
+func appendRunAllCommand(commands [][]string, commandList, userCraneCommand string) (string, error) {
 
 	for index, cranefileCommandPair := range commands {
 		if len(cranefileCommandPair) != COMMANDS_ARGUMENT_COUNT {
-			logger.Fatal("Wrong amount of command  arguments specified for the pair nr " + strconv.Itoa(index) + ": Expected " + strconv.Itoa(COMMANDS_ARGUMENT_COUNT) + ", Actual " + strconv.Itoa(len(cranefileCommandPair)) + ". Please correct(if you need to specify multiple commands please use \"<command1>;<command2>\" format.")
+			return "", wrongCommandPairError(index, cranefileCommandPair)
 		}
 
 		if len(userCraneCommand) == 0 { //All Cranefile commands
 			commandList = appendCommand(commandList, cranefileCommandPair[1])
 		} else if userCraneCommand == cranefileCommandPair[0] { //Specific Cranefile commands
-			return appendCommand(commandList, cranefileCommandPair[1])
+			return appendCommand(commandList, cranefileCommandPair[1]), nil
 		}
 	}
-	return commandList
+	return commandList, nil
 }
 
 //Appends a new command to the list of commands.
@@ -180,7 +206,7 @@ func appendCommand(commandList, part string) string {
 
 // The user can specify only one option at a time or no option a all in which case all commands in all containers are executed.
 // If the user wants to use more than one option in a sequential order he/she use multiple "runall" commands.
-func checkRunAllOptionsValidity(runallCommands []string) {
+func checkRunAllOptionsValidity(runallCommands []string) error {
 
 	optionPresent := false
 
@@ -188,9 +214,10 @@ func checkRunAllOptionsValidity(runallCommands []string) {
 		if len(command) > 0 && !optionPresent {
 			optionPresent = true
 		} else if len(command) > 0 && optionPresent {
-			logger.Fatal("Can't combine multiple options with the \"runall\" command.Please use only one of the following options at a time : \"-c\",\"-o\",\"-l\".")
+			return exit.Errorf(exit.USAGE_ERROR, "Can't combine multiple options with the \"runall\" command.Please use only one of the following options at a time : \"-c\",\"-o\",\"-l\".")
 		}
 	}
+	return nil
 }
 
 func (c *RunallCommand) Synopsis() string {
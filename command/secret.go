@@ -0,0 +1,148 @@
+package command
+
+import (
+	"flag"
+	"github.com/SnowRipple/crane/exit"
+	ownLog "github.com/SnowRipple/crane/logger"
+	"github.com/SnowRipple/crane/secret"
+	"github.com/SnowRipple/crane/terminal"
+	"github.com/mitchellh/cli"
+	"strings"
+)
+
+/*
+ Secret subcommands
+*/
+const (
+	SECRET_SET  = "set"
+	SECRET_GET  = "get"
+	SECRET_LIST = "list"
+)
+
+const SECRET_COMMAND = "secret"
+
+// SecretCommand manages secrets stored in the local encrypted keystore.
+type SecretCommand struct {
+	Ui cli.Ui
+}
+
+func (c *SecretCommand) Help() string {
+	helpText := `
+  Usage: crane secret set <secretName> [<value>]
+
+  Stores a secret in the local encrypted keystore. If the value is not provided it is read from the terminal (so it does not end up in the shell history).
+
+  Usage: crane secret get <secretName>
+
+  Prints the value of a secret.
+
+  Usage: crane secret list
+
+  Lists names of all stored secrets.
+
+  Secrets are referenced in the Cranefile instead of plain text values, e.g. PASSWORD = "secret:<secretName>".
+  Values can also be taken from environment variables (PASSWORD = "env:<VARIABLE>") or files (PASSWORD = "file:<path>").
+  The keystore passphrase is asked for or read from the CRANE_SECRETS_PASSPHRASE environment variable.
+  `
+	return strings.TrimSpace(helpText)
+}
+
+//Manage secrets in the local keystore.
+func (c *SecretCommand) Run(arguments []string) int {
+
+	logger.Debug("Entered secret command...")
+
+	cmdFlags := flag.NewFlagSet(SECRET_COMMAND, flag.ContinueOnError)
+	cmdFlags.Usage = func() { c.Ui.Output(c.Help()) }
+
+	if len(arguments) == 0 {
+		return exitWithError(exit.Errorf(exit.USAGE_ERROR, "Missing arguments.Please specify one of: %s, %s, %s.", SECRET_SET, SECRET_GET, SECRET_LIST))
+	}
+
+	keystore, err := secret.OpenKeystore()
+	if err != nil {
+		return exitWithError(exit.Errorf(exit.CONFIG_ERROR, "Failed to open the keystore due to error: %v", err))
+	}
+
+	subcommand, arguments := arguments[0], arguments[1:]
+
+	switch subcommand {
+	case SECRET_SET:
+		err = c.setSecret(keystore, arguments)
+	case SECRET_GET:
+		err = c.getSecret(keystore, arguments)
+	case SECRET_LIST:
+		for _, name := range keystore.Names() {
+			c.Ui.Output(name)
+		}
+	default:
+		err = exit.Errorf(exit.USAGE_ERROR, "Unknown secret subcommand %q.Please use one of: %s, %s, %s.", subcommand, SECRET_SET, SECRET_GET, SECRET_LIST)
+	}
+
+	if err != nil {
+		return exitWithError(err)
+	}
+	return 0
+}
+
+//Stores a secret in the keystore.
+func (c *SecretCommand) setSecret(keystore *secret.Keystore, arguments []string) error {
+
+	var (
+		value string
+		err   error
+	)
+
+	switch len(arguments) {
+	case 1:
+		if !terminal.IsTerminal() {
+			return exit.Errorf(exit.USAGE_ERROR, "No terminal to read the secret value from.Please provide it as an argument.")
+		}
+		if value, err = terminal.ReadHiddenInput("Value of " + arguments[0] + ": "); err != nil {
+			return err
+		}
+	case 2:
+		value = arguments[1]
+	default:
+		return exit.Errorf(exit.USAGE_ERROR, "Wrong arguments format.Please use: crane secret set <secretName> [<value>]")
+	}
+
+	ownLog.RegisterSecret(value)
+
+	keystore.Set(arguments[0], value)
+	if err := keystore.Save(); err != nil {
+		return exit.Errorf(exit.FILE_ERROR, "Failed to save the keystore due to error: %v", err)
+	}
+
+	logger.Notice("Secret %q saved.", arguments[0])
+	return nil
+}
+
+//Prints the value of a secret.
+func (c *SecretCommand) getSecret(keystore *secret.Keystore, arguments []string) error {
+
+	if len(arguments) != 1 {
+		return exit.Errorf(exit.USAGE_ERROR, "Wrong arguments format.Please use: crane secret get <secretName>")
+	}
+
+	value, exists := keystore.Get(arguments[0])
+	if !exists {
+		return exit.Errorf(exit.CONFIG_ERROR, "Secret %q does not exist in the keystore.", arguments[0])
+	}
+
+	c.Ui.Output(value)
+	return nil
+}
+
+//Registers the value given on the command line to crane secret set <secretName> <value> for redaction,
+//so logging the command line arguments does not leak it.
+func RegisterArgumentSecrets(commandArguments []string) {
+
+	if len(commandArguments) == 4 && commandArguments[0] == SECRET_COMMAND && commandArguments[1] == SECRET_SET {
+		ownLog.RegisterSecret(commandArguments[3])
+	}
+}
+
+func (c *SecretCommand) Synopsis() string {
+	return "Manage secrets referenced in the Cranefile."
+}
@@ -0,0 +1,55 @@
+package command
+
+import (
+	"bytes"
+	"github.com/SnowRipple/crane/constants"
+	ownLog "github.com/SnowRipple/crane/logger"
+	"github.com/SnowRipple/crane/secret"
+	"github.com/mitchellh/cli"
+	log "github.com/op/go-logging"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSecretSet_redactedFromDebugLog(t *testing.T) {
+
+	var output bytes.Buffer
+
+	log.SetBackend(log.NewLogBackend(ownLog.NewRedactingWriter(&output), "", 0))
+	log.SetLevel(log.DEBUG, ownLog.LOGGER_NAME)
+	defer ownLog.Configure(ownLog.Settings{})
+
+	t.Setenv(constants.SECRETS_FILE_VARIABLE, filepath.Join(t.TempDir(), "secrets"))
+	t.Setenv(constants.SECRETS_PASSPHRASE_VARIABLE, "keystore-passphrase-2013")
+
+	//The same steps crane takes for: crane -d secret set dbPassword <value>
+	arguments := []string{SECRET_COMMAND, SECRET_SET, "dbPassword", "orobix-db-2013"}
+	RegisterArgumentSecrets(arguments)
+	logger.Debug("Command line arguments provided: %v", arguments)
+
+	secretCommand := &SecretCommand{Ui: &cli.BasicUi{Writer: ioutil.Discard}}
+	if status := secretCommand.Run(arguments[1:]); status != 0 {
+		t.Fatalf("crane secret set exited with %d, log: %q", status, output.String())
+	}
+
+	if !strings.Contains(output.String(), "Command line arguments provided") {
+		t.Fatalf("Debug record missing from the log: %q", output.String())
+	}
+	if strings.Contains(output.String(), "orobix-db-2013") {
+		t.Errorf("Secret value leaked into the log: %q", output.String())
+	}
+
+	//Values stored by setSecret are redacted whatever way they were given
+	keystore, err := secret.OpenKeystore()
+	if err != nil {
+		t.Fatalf("Failed to open the keystore: %v", err)
+	}
+	if err := secretCommand.setSecret(keystore, []string{"apiToken", "orobix-api-2013"}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if redacted := ownLog.Redact("token orobix-api-2013"); strings.Contains(redacted, "orobix-api-2013") {
+		t.Errorf("Stored secret is not redacted: %q", redacted)
+	}
+}
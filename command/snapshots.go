@@ -0,0 +1,154 @@
+package command
+
+import (
+	"flag"
+	"fmt"
+	"github.com/SnowRipple/crane/command/executer"
+	"github.com/SnowRipple/crane/config"
+	"github.com/SnowRipple/crane/constants"
+	"github.com/SnowRipple/crane/container"
+	"github.com/SnowRipple/crane/exit"
+	ownLog "github.com/SnowRipple/crane/logger"
+	"github.com/SnowRipple/crane/output"
+	"github.com/SnowRipple/crane/utils"
+	"github.com/mitchellh/cli"
+	"strings"
+	"text/tabwriter"
+)
+
+/*
+ Snapshot states reported by the snapshots command
+*/
+const (
+	SNAPSHOT_CURRENT   = "current" //The container runs from this snapshot
+	SNAPSHOT_AVAILABLE = "available"
+	SNAPSHOT_MISSING   = "missing" //The image was removed outside of crane
+
+	LAYER_SIZE_TEMPLATE = "{{.Size}}"
+	SHORT_ID_LENGTH     = 12
+)
+
+// SnapshotsCommand lists snapshots taken by crane freeze.
+type SnapshotsCommand struct {
+	Ui     cli.Ui
+	Config config.TomlConfig
+}
+
+func (c *SnapshotsCommand) Help() string {
+	helpText := `
+  Usage: crane snapshots <containerName>
+
+  Lists snapshots of a container taken by crane freeze, oldest first: tag, image id, creation time, size of the changes
+  the snapshot added (from the image history) and whether the container currently runs from it.
+
+  Use crane --output json snapshots (or yaml) to get the snapshots in a machine readable format.
+  `
+	return strings.TrimSpace(helpText)
+}
+
+//Lists snapshots of a container.
+func (c *SnapshotsCommand) Run(arguments []string) int {
+
+	logger.Debug("Entered snapshots command...")
+
+	cmdFlags := flag.NewFlagSet("snapshots", flag.ContinueOnError)
+	cmdFlags.Usage = func() { c.Ui.Output(c.Help()) }
+
+	if len(arguments) != 1 {
+		return exitWithError(exit.Errorf(exit.USAGE_ERROR, "The snapshots command expects a single container name.Please correct."))
+	}
+	containerName := arguments[0]
+	defer ownLog.ScopeField(ownLog.CONTAINER_FIELD, containerName)()
+
+	if _, err := utils.GetRequestedContainerConfig(c.Config.CraneConfig.Containers, containerName, true); err != nil {
+		return exitWithError(err)
+	}
+	containerState, _ := utils.GetRequestedContainerState(c.Config.CraneState.StateContainers, containerName, false)
+	host, err := utils.GetRequestedHost(c.Config.CraneConfig.Hosts, c.Config.CraneConfig.Containers[containerName].Host)
+	if err != nil {
+		return exitWithError(err)
+	}
+
+	history := c.Config.CraneState.Snapshots[containerName]
+	if len(history) == 0 {
+		logger.Notice("Container %q has no snapshots yet, use crane freeze %s to take one.", containerName, containerName)
+		return 0
+	}
+
+	var (
+		actions []*output.Action
+		changes []string
+	)
+	for _, snapshot := range history {
+
+		action := output.StartAction(output.SNAPSHOT_ACTION, containerName)
+		action.Image = snapshot.Image
+		action.ImageID = snapshot.ID
+
+		layerSize, exists := snapshotChanges(host, snapshot)
+		switch {
+		case !exists:
+			action.State = SNAPSHOT_MISSING
+		case snapshot.Image == containerState.Snapshot:
+			action.State = SNAPSHOT_CURRENT
+		default:
+			action.State = SNAPSHOT_AVAILABLE
+		}
+
+		action.Finish()
+		actions = append(actions, action)
+		changes = append(changes, layerSize)
+	}
+
+	if !output.IsMachineReadable() {
+		c.printSnapshotsTable(history, actions, changes)
+	}
+	return 0
+}
+
+//Returns the size of the layer a snapshot added on top of its parent image, taken from the image history.
+func snapshotChanges(host container.Host, snapshot container.Snapshot) (string, bool) {
+
+	outputBytes, err := executer.GetCommandOutput(host.DockerCommand(constants.HISTORY, constants.FORMAT+LAYER_SIZE_TEMPLATE, snapshot.Image))
+	if err != nil {
+		logger.Debug("Failed to read the history of snapshot %q, it is treated as removed: %v", snapshot.Image, err)
+		return "", false
+	}
+	return newestLayerSize(outputBytes), true
+}
+
+//Returns the size of the newest layer from layer sizes printed by docker history, newest first.
+func newestLayerSize(historyOutput []byte) string {
+
+	layers := strings.Split(strings.TrimSpace(string(historyOutput)), "\n")
+	return strings.TrimSpace(layers[0])
+}
+
+//Prints snapshots as an aligned table.
+func (c *SnapshotsCommand) printSnapshotsTable(history []container.Snapshot, actions []*output.Action, changes []string) {
+
+	var table strings.Builder
+
+	writer := tabwriter.NewWriter(&table, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(writer, "SNAPSHOT\tIMAGE\tID\tCREATED\tCHANGES\tSTATE")
+	for index, snapshot := range history {
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%s\t%s\t%s\n", snapshot.Tag(), snapshot.Image, shortImageId(snapshot.ID), snapshot.Created, changes[index], actions[index].State)
+	}
+	writer.Flush()
+
+	c.Ui.Output(strings.TrimRight(table.String(), "\n"))
+}
+
+//Shortens an image id the way docker prints it.
+func shortImageId(imageId string) string {
+
+	imageId = strings.TrimPrefix(imageId, "sha256:")
+	if len(imageId) > SHORT_ID_LENGTH {
+		return imageId[:SHORT_ID_LENGTH]
+	}
+	return imageId
+}
+
+func (c *SnapshotsCommand) Synopsis() string {
+	return "List snapshots of a frozen container."
+}
@@ -0,0 +1,34 @@
+package command
+
+import (
+	"testing"
+)
+
+func TestNewestLayerSize(t *testing.T) {
+
+	cases := map[string]string{
+		"12.3MB\n0B\n72.8MB\n": "12.3MB",
+		"  0B  \r\n5.29MB":     "0B",
+		"\n\n1.05kB\n":         "1.05kB",
+		"":                     "",
+	}
+	for historyOutput, expected := range cases {
+		if size := newestLayerSize([]byte(historyOutput)); size != expected {
+			t.Errorf("newestLayerSize(%q) = %q; expected %q", historyOutput, size, expected)
+		}
+	}
+}
+
+func TestShortImageId(t *testing.T) {
+
+	cases := map[string]string{
+		"sha256:9dec4701263f76206323625ef05e3bf1": "9dec4701263f",
+		"9dec4701263f76206323625ef05e3bf1":        "9dec4701263f",
+		"sha256:9dec47":                           "9dec47",
+	}
+	for imageId, expected := range cases {
+		if shortId := shortImageId(imageId); shortId != expected {
+			t.Errorf("shortImageId(%q) = %q; expected %q", imageId, shortId, expected)
+		}
+	}
+}
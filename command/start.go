@@ -6,15 +6,20 @@ import (
 	"github.com/SnowRipple/crane/config"
 	"github.com/SnowRipple/crane/constants"
 	"github.com/SnowRipple/crane/container"
+	"github.com/SnowRipple/crane/dryrun"
+	"github.com/SnowRipple/crane/exit"
 	"github.com/SnowRipple/crane/io"
+	"github.com/SnowRipple/crane/output"
 	"github.com/SnowRipple/crane/utils"
 	flags "github.com/jessevdk/go-flags"
 	"github.com/mitchellh/cli"
-	"os/exec"
 	"strings"
 	"time"
 )
 
+//Prints the IP address of a container on the default network.
+const IP_ADDRESS_TEMPLATE = "{{.NetworkSettings.IPAddress}}"
+
 // StartCommand initializes all daemonized containers.
 type StartCommand struct {
 	Ui     cli.Ui
@@ -29,7 +34,9 @@ func (c *StartCommand) Help() string {
 Options:
 
   -a(--all) : Starts all daemonized containers defined in the Cranefile.
-    -f(--force) : Crane assumes that the image already exists in the host system and does not attempt to download it from the docker public repository (useful for offline mode).
+    -f(--force) : Crane assumes that the image already exists in the host system and skips the PULL_POLICY (no pull, no build) for this invocation. Use OFFLINE or --offline to work offline permanently.
+    -k(--keep-going) : Carries on starting remaining containers when one of them fails.
+    --fail-fast : Stops at the first container that failed to start (default).
     `
 
 	return strings.TrimSpace(helpText)
@@ -39,7 +46,6 @@ Options:
 func (c *StartCommand) Run(chosenContainers []string) int {
 	var (
 		stateContainers = map[string]container.StateContainer{}
-		dockerCommand   []string
 		options         constants.CommonFlags
 	)
 
@@ -49,14 +55,22 @@ func (c *StartCommand) Run(chosenContainers []string) int {
 	cmdFlags.Usage = func() { c.Ui.Output(c.Help()) }
 
 	if len(chosenContainers) == 0 {
-		logger.Fatalf("Not enough arguments provided for the start command.Please correct.")
+		return exitWithError(exit.Errorf(exit.USAGE_ERROR, "Not enough arguments provided for the start command.Please correct."))
 	}
 	chosenContainers, err := flags.ParseArgs(&options, chosenContainers)
 	if err != nil {
-		logger.Fatalf("Failed to extract flags for the start command for the following arguments:\n%v", chosenContainers)
+		return exitWithError(exit.Errorf(exit.USAGE_ERROR, "Failed to extract flags for the start command for the following arguments:\n%v", chosenContainers))
+	}
+
+	containersBatch, err := newBatch(c.Ui, options)
+	if err != nil {
+		return exitWithError(err)
 	}
 
-	for containerName, containerConfig := range c.Config.CraneConfig.Containers {
+	var containersToStart []string
+	for _, containerName := range sortedContainerNames(c.Config.CraneConfig.Containers) {
+
+		containerConfig := c.Config.CraneConfig.Containers[containerName]
 
 		if containerConfig.Daemonized == false {
 			continue //Start only daemonized containers
@@ -65,66 +79,143 @@ func (c *StartCommand) Run(chosenContainers []string) int {
 		if !options.All && !isThisContainerChosen(containerName, chosenContainers) {
 			continue //Start only chosen containers
 		}
+		containersToStart = append(containersToStart, containerName)
+	}
 
-		dockerCommand = container.BuildRunCommand(containerConfig, containerName, false, false) //no need for cidfile since in case of daemonized it is returned automatically through stdout
+	if err := checkPortConflicts(c.Config, containersToStart); err != nil {
+		return exitWithError(err)
+	}
+
+	for _, containerName := range containersToStart {
+
+		containerConfig := c.Config.CraneConfig.Containers[containerName]
 
-		if !options.ForceImage {
-			buildImageCommand := BuildImageCommand{Ui: c.Ui}
-			buildImageCommand.BuildImageIfNeeded(containerConfig)
-		} else {
-			logger.Debug("Force Image option detected. Will use host's image only")
+		containersBatch.Run(containerName, func() error {
+			stateContainer, err := c.startContainer(containerName, containerConfig, options)
+			if len(stateContainer.ID) > 0 { //Record the container even if it failed later so it can be destroyed
+				stateContainers[containerName] = stateContainer
+			}
+			return err
+		})
+	}
+	if len(stateContainers) == 0 {
+		if len(containersBatch.results) == 0 {
+			logger.Notice("No containers in the Cranefile match provided criteria hence no containers were started.")
 		}
-		//When the container is daemonized we need to be able to access it through the ssh.
-		//Hence we need to start sshd process to listen for the incoming ssh connections.
-
-		dockerCommand = append(dockerCommand, constants.SHELL_COMMAND)
-		dockerCommand = append(dockerCommand, constants.SHELL_STRING_OPTION)
-		dockerCommand = append(dockerCommand, constants.SSHD_COMMAND)
-		//Run the container
-
-		containerIdBytes, err := executer.GetCommandOutput(dockerCommand)
-		if err != nil {
-			logger.Fatal("Error starting daemonized container:", utils.ExtractContainerMessage(containerIdBytes, err))
-		} else {
-			logger.Notice("Successfully started container %q...", containerName)
+	} else if err := io.UpdateStateFile(stateContainers); err != nil {
+		containersBatch.Finish()
+		return exitWithError(err)
+	}
+
+	return containersBatch.Finish()
+}
+
+//Starts a single daemonized container with the sshd process listening for incoming ssh connections.
+func (c *StartCommand) startContainer(containerName string, containerConfig container.Container, options constants.CommonFlags) (container.StateContainer, error) {
+
+	action := output.StartAction(output.START_ACTION, containerName)
+	action.Image = containerConfig.Image
+
+	host, err := utils.GetRequestedHost(c.Config.CraneConfig.Hosts, containerConfig.Host)
+	if err != nil {
+		return container.StateContainer{}, err
+	}
+
+	dockerCommand, err := container.BuildRunCommand(host, containerConfig, containerName, false, false) //no need for cidfile since in case of daemonized it is returned automatically through stdout
+	if err != nil {
+		return container.StateContainer{}, err
+	}
+
+	if !options.ForceImage {
+		buildImageCommand := BuildImageCommand{Ui: c.Ui}
+		if err := buildImageCommand.PrepareImage(host, containerName, containerConfig); err != nil {
+			return container.StateContainer{}, err
 		}
+	} else {
+		logger.Debug("Force Image option detected. Will use host's image only")
+	}
+
+	if err := createNamedVolumes(host, containerName, containerConfig); err != nil {
+		return container.StateContainer{}, err
+	}
+	//When the container is daemonized we need to be able to access it through the ssh.
+	//Hence we need to start sshd process to listen for the incoming ssh connections.
+
+	dockerCommand = append(dockerCommand, constants.SHELL_COMMAND)
+	dockerCommand = append(dockerCommand, constants.SHELL_STRING_OPTION)
+	dockerCommand = append(dockerCommand, constants.SSHD_COMMAND)
+	//Run the container
 
+	containerIdBytes, err := executer.GetCommandOutput(dockerCommand)
+	if err != nil {
+		return container.StateContainer{}, exit.Errorf(exit.DOCKER_ERROR, "Error starting daemonized container:%s", utils.ExtractContainerMessage(containerIdBytes, err))
+	} else {
+		logger.Notice("Successfully started container %q...", containerName)
+	}
+
+	if !dryrun.Enabled() {
 		time.Sleep(1 * time.Second) //wait for the docker to do it's magic.
+	}
 
-		//Get Container ID
-		containerId := strings.TrimSpace(string(containerIdBytes))
-		logger.Debug("Container %q ID is %q", containerName, containerId)
-		//Get Container IP address
-		ipAddress := getContainerIP(containerId)
-		logger.Debug("Container %q IP is %q", containerName, ipAddress)
+	//Get Container ID
+	containerId := strings.TrimSpace(string(containerIdBytes))
+	logger.Debug("Container %q ID is %q", containerName, containerId)
+	action.ContainerID = containerId
+	trackCreatedContainer(containerName, containerId, host)
 
-		stateContainers[containerName] = container.StateContainer{ID: containerId, IP: ipAddress}
+	stateContainer := container.StateContainer{ID: containerId, Host: containerConfig.Host}
 
+	//Get host ports assigned by the daemon
+	stateContainer.Ports = getPublishedPorts(host, containerId)
+	action.Ports = stateContainer.Ports
+
+	//Get Container IP address
+	ipAddress, err := getContainerIP(host, containerId)
+	if _, published := stateContainer.PublishedAddress(container.SSH_CONTAINER_PORT); err != nil && published {
+		logger.Warning("%v.Its published ssh port is used instead.", err)
+	} else if err != nil {
+		return stateContainer, err
 	}
-	if len(stateContainers) == 0 {
-		logger.Notice("No containers in the Cranefile match provided criteria hence no containers were started.")
-	} else {
-		io.UpdateStateFile(stateContainers)
+	logger.Debug("Container %q IP is %q", containerName, ipAddress)
+
+	stateContainer.IP = ipAddress
+	action.IP = ipAddress
+	action.Finish()
+
+	return stateContainer, nil
+}
+
+//Asks the daemon which host ports it published a container's ports at.Auto-assigned ports are known only now.
+func getPublishedPorts(host container.Host, containerID string) []string {
+
+	outputBytes, err := executer.GetCommandOutput(host.DockerCommand(constants.PORT, containerID))
+	if err != nil {
+		logger.Warning("Failed to read published ports of container %s:%s", containerID, utils.ExtractContainerMessage(outputBytes, err))
+		return nil
 	}
 
-	return 0
+	ports := container.ParsePublishedPorts(string(outputBytes))
+	logger.Debug("Container %q publishes %q", containerID, ports)
+	return ports
 }
 
 //Extracts containers's ip address using docker inspect command.
-func getContainerIP(containerID string) string {
+func getContainerIP(host container.Host, containerID string) (string, error) {
 
-	//We have to pipe multiple commands in order to get IP address of a container
-	inspectCommand := exec.Command(constants.SUDO, constants.DOCKER, constants.INSPECT, containerID)
-	grepCommand := exec.Command(constants.GREP, "IPAddress")
-	cutCommand := exec.Command("cut", "-d\"", "-f4")
+	outputBytes, err := executer.GetCommandOutput(host.DockerCommand(constants.INSPECT, constants.FORMAT+IP_ADDRESS_TEMPLATE, containerID))
+	if err != nil {
+		return "", exit.Errorf(exit.DOCKER_ERROR, "Failed to inspect the container %s:%s", containerID, utils.ExtractContainerMessage(outputBytes, err))
+	}
 
-	ipAddress := strings.TrimSpace(executer.PipeCommands(inspectCommand, grepCommand, cutCommand))
-	if len(ipAddress) == 0 {
-		logger.Fatal("Failed to obtain the IP Address for the container " + containerID + ". Invalid commands? Container is not able to run commands? Please investigate.")
+	ipAddress := strings.TrimSpace(string(outputBytes))
+	if len(ipAddress) == 0 && executer.Runtime() == executer.RUNTIME_PODMAN {
+		return "", exit.Errorf(exit.DOCKER_ERROR, "Container %s has no IP address.Rootless Podman containers are not reachable from the host so daemonized containers (ssh) require Podman running as root (--escalation=sudo).", containerID)
+	} else if len(ipAddress) == 0 {
+		return "", exit.Errorf(exit.DOCKER_ERROR, "Failed to obtain the IP Address for the container %s. Invalid commands? Container is not able to run commands? Please investigate.", containerID)
 	}
 	logger.Debug(" Container %q has IP %q", containerID, ipAddress)
 
-	return ipAddress
+	return ipAddress, nil
 }
 
 func (c *StartCommand) Synopsis() string {
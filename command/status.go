@@ -0,0 +1,144 @@
+package command
+
+import (
+	"flag"
+	"fmt"
+	"github.com/SnowRipple/crane/command/executer"
+	"github.com/SnowRipple/crane/config"
+	"github.com/SnowRipple/crane/constants"
+	"github.com/SnowRipple/crane/container"
+	"github.com/SnowRipple/crane/exit"
+	ownLog "github.com/SnowRipple/crane/logger"
+	"github.com/SnowRipple/crane/output"
+	"github.com/SnowRipple/crane/utils"
+	"github.com/mitchellh/cli"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+/*
+ Container states reported by the status command
+*/
+const (
+	STATE_RUNNING     = "running"
+	STATE_STOPPED     = "stopped"
+	STATE_NOT_CREATED = "not created"
+
+	RUNNING_TEMPLATE = "{{.State.Running}}"
+)
+
+// StatusCommand shows the state of containers defined in the Cranefile.
+type StatusCommand struct {
+	Ui     cli.Ui
+	Config config.TomlConfig
+}
+
+func (c *StatusCommand) Help() string {
+	helpText := `
+  Usage: crane status
+
+  Shows the state (running, stopped or not created), id, ip, image and resource limits of all containers defined in the Cranefile.
+
+  Usage: crane status <containerName1> <containerName2>
+
+  Shows the state of chosen containers only.
+
+  Use crane --output json status (or yaml) to get the states in a machine readable format.
+  `
+	return strings.TrimSpace(helpText)
+}
+
+//Show the state of containers.
+func (c *StatusCommand) Run(containerNames []string) int {
+
+	logger.Debug("Entered status command...")
+
+	cmdFlags := flag.NewFlagSet("status", flag.ContinueOnError)
+	cmdFlags.Usage = func() { c.Ui.Output(c.Help()) }
+
+	if len(containerNames) == 0 {
+		for containerName, _ := range c.Config.CraneConfig.Containers {
+			containerNames = append(containerNames, containerName)
+		}
+	}
+	sort.Strings(containerNames)
+
+	var actions []*output.Action
+
+	defer ownLog.RemoveField(ownLog.CONTAINER_FIELD) //The last container must not stick to later records
+	for _, containerName := range containerNames {
+
+		ownLog.SetField(ownLog.CONTAINER_FIELD, containerName)
+
+		containerConfig, exists := c.Config.CraneConfig.Containers[containerName]
+		if !exists {
+			return exitWithError(exit.Errorf(exit.CONFIG_ERROR, "Container %q is not defined in the Cranefile.Please correct.", containerName))
+		}
+
+		action := output.StartAction(output.STATUS_ACTION, containerName)
+		action.Image = containerConfig.Image
+		action.Limits = containerConfig.Resources.String()
+
+		containerState, exists := c.Config.CraneState.StateContainers[containerName]
+		if exists {
+			action.ContainerID = containerState.ID
+			action.IP = containerState.IP
+			action.Ports = containerState.Ports
+		}
+		host, err := utils.GetRequestedHost(c.Config.CraneConfig.Hosts, containerState.Host) //Ask the daemon that owns the container
+		if err != nil {
+			return exitWithError(err)
+		}
+		action.State = getContainerState(host, action.ContainerID)
+
+		action.Finish()
+		actions = append(actions, action)
+	}
+
+	if !output.IsMachineReadable() {
+		c.printStatusTable(actions)
+	}
+
+	return 0
+}
+
+//Asks docker whether a container created by crane is still running.
+func getContainerState(host container.Host, containerId string) string {
+
+	if len(containerId) == 0 {
+		return STATE_NOT_CREATED
+	}
+
+	dockerCommand := host.DockerCommand(constants.INSPECT, constants.FORMAT+RUNNING_TEMPLATE, containerId)
+
+	outputBytes, err := executer.GetCommandOutput(dockerCommand)
+	if err != nil { //Container was removed outside of crane
+		logger.Debug("Failed to inspect container %q, it is treated as not created: %v", containerId, err)
+		return STATE_NOT_CREATED
+	}
+
+	if strings.TrimSpace(string(outputBytes)) == "true" {
+		return STATE_RUNNING
+	}
+	return STATE_STOPPED
+}
+
+//Prints containers' states as an aligned table.
+func (c *StatusCommand) printStatusTable(actions []*output.Action) {
+
+	var table strings.Builder
+
+	writer := tabwriter.NewWriter(&table, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(writer, "CONTAINER\tSTATE\tID\tIP\tIMAGE\tLIMITS\tPORTS")
+	for _, action := range actions {
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", action.Container, action.State, action.ContainerID, action.IP, action.Image, action.Limits, strings.Join(action.Ports, ", "))
+	}
+	writer.Flush()
+
+	c.Ui.Output(strings.TrimRight(table.String(), "\n"))
+}
+
+func (c *StatusCommand) Synopsis() string {
+	return "Show the state of containers."
+}
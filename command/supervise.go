@@ -0,0 +1,296 @@
+package command
+
+import (
+	"fmt"
+	"github.com/SnowRipple/crane/cancellation"
+	"github.com/SnowRipple/crane/command/executer"
+	"github.com/SnowRipple/crane/config"
+	"github.com/SnowRipple/crane/constants"
+	"github.com/SnowRipple/crane/container"
+	"github.com/SnowRipple/crane/dryrun"
+	"github.com/SnowRipple/crane/exit"
+	"github.com/SnowRipple/crane/io"
+	"github.com/SnowRipple/crane/utils"
+	flags "github.com/jessevdk/go-flags"
+	"github.com/mitchellh/cli"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	DEFAULT_SUPERVISE_INTERVAL = 5 * time.Second
+	STABLE_RUN_PERIOD          = 1 * time.Minute //A container running that long after a restart has recovered, its backoff starts over
+
+	EXIT_TEMPLATE     = "{{.State.Running}} {{.State.ExitCode}}"
+	REMOVED_EXIT_CODE = -1 //Reported for containers removed outside of crane
+)
+
+// SuperviseCommand watches daemonized containers and restarts them according to their RESTART policy.
+type SuperviseCommand struct {
+	Ui     cli.Ui
+	Config config.TomlConfig
+}
+
+//Restart bookkeeping of a supervised container.
+type supervisedContainer struct {
+	name                string
+	config              container.Container
+	policy              container.RestartPolicy
+	restarts            int
+	consecutiveFailures int
+	lastRestart         time.Time
+	nextRestart         time.Time //Zero when no restart is scheduled
+	abandonedID         string    //Stopped container that will not be restarted
+}
+
+func (c *SuperviseCommand) Help() string {
+	helpText := `
+  Usage: crane supervise [options]
+
+  Watches all daemonized containers with a RESTART policy in the foreground and restarts them when they stop.
+  Restarted containers get new ids and ips which are written into the state file.Every restart is logged and appended to the .crane_restarts file.
+  Press Ctrl-C to stop supervising, containers keep running.
+
+  Usage: crane supervise [options] <containerName1> <containerName2>
+
+  Watches chosen containers only.
+
+Options:
+
+  --interval : How often containers are checked (default 5s).
+  -f(--force) : Restarted containers use the host's image without looking for it in the docker public repository.
+  `
+	return strings.TrimSpace(helpText)
+}
+
+//Checks containers every interval until crane is interrupted.
+func (c *SuperviseCommand) Run(arguments []string) int {
+
+	var options constants.CommonFlags
+
+	logger.Debug("Entered supervise command...")
+
+	chosenContainers, err := flags.ParseArgs(&options, arguments)
+	if err != nil {
+		return exitWithError(exit.Errorf(exit.USAGE_ERROR, "Failed to parse supervise options due to error:%v", err))
+	}
+
+	supervised, err := c.supervisedContainers(chosenContainers)
+	if err != nil {
+		return exitWithError(err)
+	}
+
+	interval := options.SuperviseInterval
+	if interval <= 0 {
+		interval = DEFAULT_SUPERVISE_INTERVAL
+	}
+	logger.Notice("Supervising %d container(s) every %v...", len(supervised), interval)
+
+	for {
+		c.superviseOnce(supervised, options)
+
+		if dryrun.Enabled() { //A single pass shows what would be done
+			return 0
+		}
+
+		select {
+		case <-cancellation.Done():
+			logger.Notice("Stopped supervising containers.")
+			return 0
+		case <-time.After(interval):
+		}
+	}
+}
+
+//Returns chosen (or all) daemonized containers with a restart policy.
+func (c *SuperviseCommand) supervisedContainers(chosenContainers []string) ([]*supervisedContainer, error) {
+
+	var supervised []*supervisedContainer
+
+	for _, containerName := range chosenContainers {
+		if _, exists := c.Config.CraneConfig.Containers[containerName]; !exists {
+			return nil, exit.Errorf(exit.CONFIG_ERROR, "Container %q is not defined in the Cranefile.Please correct.", containerName)
+		}
+	}
+
+	for _, containerName := range sortedContainerNames(c.Config.CraneConfig.Containers) {
+
+		containerConfig := c.Config.CraneConfig.Containers[containerName]
+
+		if len(chosenContainers) > 0 && !isThisContainerChosen(containerName, chosenContainers) {
+			continue
+		}
+
+		policy, err := container.ParseRestartPolicy(containerConfig.Restart, containerConfig.RestartBackoff)
+		if err != nil {
+			return nil, err
+		}
+		if policy.Name == container.RESTART_NO {
+			logger.Debug("Container %q has no restart policy, it will not be supervised", containerName)
+			continue
+		}
+
+		supervised = append(supervised, &supervisedContainer{name: containerName, config: containerConfig, policy: policy})
+	}
+
+	if len(supervised) == 0 {
+		return nil, exit.Errorf(exit.CONFIG_ERROR, "No daemonized containers with a RESTART policy were found hence there is nothing to supervise.Please set RESTART in the Cranefile.")
+	}
+	return supervised, nil
+}
+
+//Checks every supervised container once and restarts the ones that are due.
+func (c *SuperviseCommand) superviseOnce(supervised []*supervisedContainer, options constants.CommonFlags) {
+
+	currentConfig, err := config.ReadConfig() //Other crane invocations (e.g. destroy) may have changed the state file
+	if err != nil {
+		logger.Error("Failed to read the state file, containers will be checked later: %v", err)
+		return
+	}
+
+	for _, supervisedContainer := range supervised {
+
+		stateContainer, exists := currentConfig.CraneState.StateContainers[supervisedContainer.name]
+		if !exists { //Not started yet or destroyed, nothing to restart
+			continue
+		}
+
+		host, err := utils.GetRequestedHost(c.Config.CraneConfig.Hosts, stateContainer.Host)
+		if err != nil {
+			logger.Error("Container %q can't be supervised: %v", supervisedContainer.name, err)
+			continue
+		}
+
+		c.superviseContainer(supervisedContainer, stateContainer, host, options)
+	}
+}
+
+//Restarts a single container if it stopped and its policy says so.
+func (c *SuperviseCommand) superviseContainer(supervised *supervisedContainer, stateContainer container.StateContainer, host container.Host, options constants.CommonFlags) {
+
+	running, exitCode, err := inspectContainerExit(host, stateContainer.ID)
+	if err != nil { //A container that may still be running must not be replaced
+		logger.Warning("Failed to check container %q, it will be checked again later: %v", supervised.name, err)
+		return
+	}
+	now := time.Now()
+
+	if running {
+		if supervised.consecutiveFailures > 0 && now.Sub(supervised.lastRestart) >= STABLE_RUN_PERIOD {
+			logger.Debug("Container %q has been running for %v, its backoff starts over", supervised.name, STABLE_RUN_PERIOD)
+			supervised.consecutiveFailures = 0
+		}
+		supervised.nextRestart = time.Time{}
+		return
+	}
+
+	if supervised.abandonedID == stateContainer.ID {
+		return
+	}
+
+	if !supervised.policy.ShouldRestart(exitCode, supervised.restarts) {
+		if exitCode == 0 {
+			logger.Notice("Container %q exited successfully, it is not restarted due to %q restart policy.", supervised.name, supervised.policy.Name)
+		} else {
+			logger.Error("Container %q exited with code %d and it is not restarted anymore after %d restart(s).", supervised.name, exitCode, supervised.restarts)
+		}
+		supervised.abandonedID = stateContainer.ID
+		return
+	}
+
+	if supervised.nextRestart.IsZero() {
+		delay := supervised.policy.Delay(supervised.consecutiveFailures)
+		if dryrun.Enabled() {
+			delay = 0
+		}
+		supervised.nextRestart = now.Add(delay)
+		logger.Warning("Container %q stopped with exit code %d, restarting in %v...", supervised.name, exitCode, delay)
+	}
+	if now.Before(supervised.nextRestart) {
+		return
+	}
+
+	newState, err := c.restartContainer(supervised, stateContainer, host, options)
+
+	supervised.restarts++
+	supervised.consecutiveFailures++
+	supervised.lastRestart = now
+	supervised.nextRestart = time.Time{}
+
+	recordRestart(supervised, stateContainer.ID, newState.ID, exitCode, err)
+}
+
+//Removes the stopped container and starts a new one, the state file gets the new id and ip.
+func (c *SuperviseCommand) restartContainer(supervised *supervisedContainer, stateContainer container.StateContainer, host container.Host, options constants.CommonFlags) (container.StateContainer, error) {
+
+	if outputBytes, err := executer.GetCommandOutput(host.DockerCommand(constants.REMOVE, constants.FORCE, stateContainer.ID)); err != nil {
+		logger.Debug("Failed to remove stopped container %q, it is probably gone already:%s", supervised.name, utils.ExtractContainerMessage(outputBytes, err))
+	}
+
+	containerConfig := supervised.config
+	if len(stateContainer.Snapshot) > 0 { //Rolled back containers keep running their snapshot
+		containerConfig = snapshotConfig(containerConfig, stateContainer.Snapshot)
+	}
+
+	startCommand := StartCommand{Ui: c.Ui, Config: c.Config}
+	newState, err := startCommand.startContainer(supervised.name, containerConfig, options)
+
+	if len(newState.ID) > 0 { //Record the container even if it failed later so it can be destroyed
+		newState.Snapshot = stateContainer.Snapshot
+		if stateErr := io.UpdateStateFile(map[string]container.StateContainer{supervised.name: newState}); stateErr != nil && err == nil {
+			err = stateErr
+		}
+		untrackCreatedContainer(supervised.name) //Restarted containers outlive the supervision
+	}
+	return newState, err
+}
+
+//Logs a restart and appends it to the restart history file.
+func recordRestart(supervised *supervisedContainer, oldID, newID string, exitCode int, err error) {
+
+	entry := fmt.Sprintf("%s container=%s exit_code=%d restart=%d old_id=%s new_id=%s", time.Now().Format(time.RFC3339), supervised.name, exitCode, supervised.restarts, oldID, newID)
+
+	if err != nil {
+		entry += " error=" + strconv.Quote(singleLine(err.Error()))
+		logger.Error("Restart %d of container %q failed: %v", supervised.restarts, supervised.name, err)
+	} else {
+		logger.Notice("Restarted container %q (restart %d), new id is %q", supervised.name, supervised.restarts, newID)
+	}
+
+	if historyErr := io.AppendLine(entry, constants.RESTART_HISTORY_FILE); historyErr != nil {
+		logger.Error("Failed to record the restart of container %q: %v", supervised.name, historyErr)
+	}
+}
+
+//Asks docker whether a container is running and with what code it exited otherwise.
+//Only a container docker does not know about is reported as removed, any other failure is returned.
+func inspectContainerExit(host container.Host, containerId string) (running bool, exitCode int, err error) {
+
+	outputBytes, err := executer.GetCommandOutput(host.DockerCommand(constants.INSPECT, constants.FORMAT+EXIT_TEMPLATE, containerId))
+	if utils.IsMissingObject(outputBytes, err) {
+		logger.Debug("Container %q was removed outside of crane", containerId)
+		return false, REMOVED_EXIT_CODE, nil
+	} else if err != nil {
+		return false, 0, exit.Errorf(exit.DOCKER_ERROR, "Failed to inspect container %q:%s", containerId, utils.ExtractContainerMessage(outputBytes, err))
+	}
+
+	if dryrun.Enabled() { //Nothing was inspected, the single pass shows what a restart would do
+		return false, REMOVED_EXIT_CODE, nil
+	}
+
+	fields := strings.Fields(string(outputBytes))
+	if len(fields) != 2 {
+		return false, 0, exit.Errorf(exit.DOCKER_ERROR, "Unexpected state %q of container %q", strings.TrimSpace(string(outputBytes)), containerId)
+	}
+
+	exitCode, err = strconv.Atoi(fields[1])
+	if err != nil {
+		return false, 0, exit.Errorf(exit.DOCKER_ERROR, "Unexpected exit code %q of container %q", fields[1], containerId)
+	}
+	return fields[0] == "true", exitCode, nil
+}
+
+func (c *SuperviseCommand) Synopsis() string {
+	return "Restart stopped containers according to their restart policy."
+}
@@ -0,0 +1,63 @@
+package command
+
+import (
+	"github.com/SnowRipple/crane/command/executer"
+	"github.com/SnowRipple/crane/constants"
+	"github.com/SnowRipple/crane/container"
+	"github.com/SnowRipple/crane/exit"
+	"github.com/SnowRipple/crane/utils"
+	"strings"
+)
+
+/*
+ Named volumes managed by crane
+*/
+const (
+	MANAGED_LABEL       = "crane.managed"
+	MANAGED_LABEL_VALUE = "true"
+	MANAGED_TEMPLATE    = "{{index .Labels \"" + MANAGED_LABEL + "\"}}"
+)
+
+//Creates named volumes of a container that do not exist yet.They are labelled so destroy --volumes removes only volumes created by crane.
+func createNamedVolumes(host container.Host, containerName string, containerConfig container.Container) error {
+
+	for _, volume := range container.NamedVolumes(containerConfig.Mountpoints) {
+
+		if _, err := executer.GetCommandOutput(host.DockerCommand(constants.VOLUME, constants.INSPECT, volume)); err == nil {
+			logger.Debug("Volume %q already exists, it is reused.", volume)
+			continue
+		}
+
+		outputBytes, err := executer.GetCommandOutput(host.DockerCommand(constants.VOLUME, constants.CREATE, container.LABEL_OPTION+MANAGED_LABEL+"="+MANAGED_LABEL_VALUE, volume))
+		if err != nil {
+			return exit.Errorf(exit.DOCKER_ERROR, "Failed to create volume %q of container %q:%s", volume, containerName, utils.ExtractContainerMessage(outputBytes, err))
+		}
+		logger.Notice("Created volume %q for container %q", volume, containerName)
+	}
+	return nil
+}
+
+//Removes named volumes of a destroyed container that were created by crane.
+//Volumes still used by other containers can't be removed, this is reported but does not fail the destroy.
+func removeNamedVolumes(host container.Host, containerName string, containerConfig container.Container) {
+
+	for _, volume := range container.NamedVolumes(containerConfig.Mountpoints) {
+
+		outputBytes, err := executer.GetCommandOutput(host.DockerCommand(constants.VOLUME, constants.INSPECT, constants.FORMAT+MANAGED_TEMPLATE, volume))
+		if err != nil {
+			logger.Debug("Volume %q of container %q does not exist anymore", volume, containerName)
+			continue
+		}
+		if strings.TrimSpace(string(outputBytes)) != MANAGED_LABEL_VALUE {
+			logger.Notice("Volume %q of container %q was not created by crane, it is kept.", volume, containerName)
+			continue
+		}
+
+		if outputBytes, err := executer.GetCommandOutput(host.DockerCommand(constants.VOLUME, constants.REMOVE, volume)); err != nil {
+			logger.Warning("Failed to remove volume %q of container %q (still used by another container?):%s", volume, containerName, utils.ExtractContainerMessage(outputBytes, err))
+			continue
+		}
+		logger.Notice("Removed volume %q of container %q", volume, containerName)
+	}
+}
+
@@ -2,16 +2,17 @@ package main
 
 import (
 	"github.com/SnowRipple/crane/command"
+	"github.com/SnowRipple/crane/command/executer"
 	"github.com/SnowRipple/crane/config"
+	"github.com/SnowRipple/crane/output"
 	"github.com/mitchellh/cli"
-	"os"
 )
 
 // Commands is the mapping of all the available Crane commands.
 var Commands map[string]cli.CommandFactory
 
 func init() {
-	ui := &cli.BasicUi{Writer: os.Stdout}
+	ui := &cli.BasicUi{Writer: output.Console}
 
 	Commands = map[string]cli.CommandFactory{
 
@@ -22,68 +23,132 @@ func init() {
 		},
 
 		"pull": func() (cli.Command, error) {
+			craneConfig, err := readConfig()
 			return &command.PullCommand{
 				Ui:         ui,
-				Containers: config.ReadConfig().CraneConfig.Containers,
-			}, nil
+				Containers: craneConfig.CraneConfig.Containers,
+			}, err
 		},
 
 		"rmi": func() (cli.Command, error) {
+			craneConfig, err := readConfig()
 			return &command.RemoveImageCommand{
 				Ui:         ui,
-				Containers: config.ReadConfig().CraneConfig.Containers,
-			}, nil
+				Containers: craneConfig.CraneConfig.Containers,
+			}, err
 		},
 
 		"start": func() (cli.Command, error) {
+			craneConfig, err := readConfig()
 			return &command.StartCommand{
 				Ui:     ui,
-				Config: config.ReadConfig(),
-			}, nil
+				Config: craneConfig,
+			}, err
 		},
 
 		"destroy": func() (cli.Command, error) {
+			craneConfig, err := readConfig()
 			return &command.DestroyCommand{
 				Ui:     ui,
-				Config: config.ReadConfig(),
-			}, nil
+				Config: craneConfig,
+			}, err
 		},
 
 		"build": func() (cli.Command, error) {
+			craneConfig, err := readConfig()
 			return &command.BuildImageCommand{
 				Ui:         ui,
-				Containers: config.ReadConfig().CraneConfig.Containers,
-			}, nil
+				Hosts:      craneConfig.CraneConfig.Hosts,
+				Containers: craneConfig.CraneConfig.Containers,
+			}, err
 		},
 
 		"run": func() (cli.Command, error) {
+			craneConfig, err := readConfig()
 			return &command.RunCommand{
 				Ui:     ui,
-				Config: config.ReadConfig(),
-			}, nil
+				Config: craneConfig,
+			}, err
 		},
 
 		"runall": func() (cli.Command, error) {
+			craneConfig, err := readConfig()
 			return &command.RunallCommand{
 				Ui:     ui,
-				Config: config.ReadConfig(),
-			}, nil
+				Config: craneConfig,
+			}, err
 		},
 
 		"enter": func() (cli.Command, error) {
+			craneConfig, err := readConfig()
 			return &command.EnterCommand{
 				Ui:     ui,
-				Config: config.ReadConfig(),
-			}, nil
+				Config: craneConfig,
+			}, err
 		},
 
 		"freeze": func() (cli.Command, error) {
+			craneConfig, err := readConfig()
 			return &command.FreezeCommand{
 				Ui:     ui,
-				Config: config.ReadConfig(),
+				Config: craneConfig,
+			}, err
+		},
+
+		"cp": func() (cli.Command, error) {
+			craneConfig, err := readConfig()
+			return &command.CopyCommand{
+				Ui:     ui,
+				Config: craneConfig,
+			}, err
+		},
+
+		"forward": func() (cli.Command, error) {
+			craneConfig, err := readConfig()
+			return &command.ForwardCommand{
+				Ui:     ui,
+				Config: craneConfig,
+			}, err
+		},
+
+		"rollback": func() (cli.Command, error) {
+			craneConfig, err := readConfig()
+			return &command.RollbackCommand{
+				Ui:     ui,
+				Config: craneConfig,
+			}, err
+		},
+
+		"secret": func() (cli.Command, error) {
+			return &command.SecretCommand{
+				Ui: ui,
 			}, nil
 		},
 
+		"snapshots": func() (cli.Command, error) {
+			craneConfig, err := readConfig()
+			return &command.SnapshotsCommand{
+				Ui:     ui,
+				Config: craneConfig,
+			}, err
+		},
+
+		"status": func() (cli.Command, error) {
+			craneConfig, err := readConfig()
+			return &command.StatusCommand{
+				Ui:     ui,
+				Config: craneConfig,
+			}, err
+		},
+
+		"supervise": func() (cli.Command, error) {
+			craneConfig, err := readConfig()
+			return &command.SuperviseCommand{
+				Ui:     ui,
+				Config: craneConfig,
+			}, err
+		},
+
 		"version": func() (cli.Command, error) {
 			return &command.VersionCommand{
 				Revision: GitCommit,
@@ -94,3 +159,14 @@ func init() {
 		},
 	}
 }
+
+//Reads the Cranefile and the state file and applies project wide settings from the Cranefile.
+func readConfig() (config.TomlConfig, error) {
+
+	craneConfig, err := config.ReadConfig()
+	if err != nil {
+		return craneConfig, err
+	}
+	executer.EnableOffline(craneConfig.Offline)
+	return craneConfig, executer.SetProjectRuntime(craneConfig.Runtime)
+}
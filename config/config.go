@@ -4,8 +4,10 @@ import (
 	"github.com/BurntSushi/toml"
 	"github.com/SnowRipple/crane/constants"
 	"github.com/SnowRipple/crane/container"
+	"github.com/SnowRipple/crane/exit"
 	"github.com/SnowRipple/crane/io"
 	log "github.com/SnowRipple/crane/logger"
+	"github.com/SnowRipple/crane/secret"
 )
 
 type TomlConfig struct {
@@ -14,17 +16,22 @@ type TomlConfig struct {
 }
 
 type CraneConfig struct {
+	Runtime    string //Container runtime of the project: docker (default) or podman
+	PullPolicy string `toml:"PULL_POLICY"` //Default pull policy of containers
+	Offline    bool   //Never reach image registries
+	Hosts      map[string]container.Host
 	Containers map[string]container.Container
 }
 
 type CraneState struct {
 	StateContainers map[string]container.StateContainer
+	Snapshots       map[string][]container.Snapshot //Snapshot history of frozen containers, oldest first
 }
 
 var logger = log.GetLogger()
 
 //Reads config  and state files.
-func ReadConfig() TomlConfig {
+func ReadConfig() (TomlConfig, error) {
 
 	var (
 		config CraneConfig
@@ -34,25 +41,77 @@ func ReadConfig() TomlConfig {
 	//Create example state and config files if they do not exist already.
 	if exists, _ := io.CheckIfFileExists(constants.STATE_FILE); !exists {
 		logger.Debug("Creating new state file %q", constants.STATE_FILE)
-		io.CreateNewStateFile()
+		if err := io.CreateNewStateFile(); err != nil {
+			return TomlConfig{}, err
+		}
 	}
 
 	if exists, _ := io.CheckIfFileExists(constants.CONFIGURATION_FILE); !exists {
 
 		logger.Debug("Creating new configuration file %q", constants.CONFIGURATION_FILE)
-		io.CreateNewConfigFile()
+		if err := io.CreateNewConfigFile(); err != nil {
+			return TomlConfig{}, err
+		}
 	}
 
 	//Decode configuration file
 	_, err := toml.DecodeFile(constants.CONFIGURATION_FILE, &config)
 	if err != nil {
-		logger.Fatalf("Failed to decode %q file due to error:", constants.CONFIGURATION_FILE, err)
+		return TomlConfig{}, exit.Errorf(exit.CONFIG_ERROR, "Failed to decode %q file due to error:%v", constants.CONFIGURATION_FILE, err)
 	}
 
 	//Decode state file
 	_, err = toml.DecodeFile(constants.STATE_FILE, &state)
 	if err != nil {
-		logger.Fatalf("Failed to decode %q file due to error:", constants.STATE_FILE, err)
+		return TomlConfig{}, exit.Errorf(exit.CONFIG_ERROR, "Failed to decode %q file due to error:%v", constants.STATE_FILE, err)
+	}
+
+	for hostName, host := range config.Hosts {
+		if !host.IsValidAddress() {
+			return TomlConfig{}, exit.Errorf(exit.CONFIG_ERROR, "Host %q has invalid ADDRESS %q.Please use a unix://, tcp:// or ssh:// address.", hostName, host.Address)
+		}
+	}
+
+	if err := container.ValidatePullPolicy(config.PullPolicy); err != nil {
+		return TomlConfig{}, err
+	}
+
+	//Every container must run on a known host with valid resource limits, security settings, build settings, pull policy and restart policy
+	for containerName, containerConfig := range config.Containers {
+		if _, exists := config.Hosts[containerConfig.Host]; len(containerConfig.Host) > 0 && !exists {
+			return TomlConfig{}, exit.Errorf(exit.CONFIG_ERROR, "Container %q runs on host %q which is not defined in the [hosts] section of %q.Please correct.", containerName, containerConfig.Host, constants.CONFIGURATION_FILE)
+		}
+		if err := containerConfig.Resources.Validate(); err != nil {
+			return TomlConfig{}, exit.Errorf(exit.CONFIG_ERROR, "Container %q: %v", containerName, err)
+		}
+		if err := containerConfig.Security.Validate(); err != nil {
+			return TomlConfig{}, exit.Errorf(exit.CONFIG_ERROR, "Container %q: %v", containerName, err)
+		}
+		if err := containerConfig.Build.Validate(); err != nil {
+			return TomlConfig{}, exit.Errorf(exit.CONFIG_ERROR, "Container %q: %v", containerName, err)
+		}
+		if err := container.ValidatePullPolicy(containerConfig.PullPolicy); err != nil {
+			return TomlConfig{}, exit.Errorf(exit.CONFIG_ERROR, "Container %q: %v", containerName, err)
+		}
+		if containerConfig.PullPolicy == container.PULL_BUILD && !containerConfig.HasBuildSources() {
+			return TomlConfig{}, exit.Errorf(exit.CONFIG_ERROR, "Container %q: PULL_POLICY %q requires DOCKERFILE or a build CONTEXT.Please correct.", containerName, container.PULL_BUILD)
+		}
+		containerConfig.PullPolicy = containerConfig.EffectivePullPolicy(config.PullPolicy)
+		config.Containers[containerName] = containerConfig
+		restartPolicy, err := container.ParseRestartPolicy(containerConfig.Restart, containerConfig.RestartBackoff)
+		if err != nil {
+			return TomlConfig{}, exit.Errorf(exit.CONFIG_ERROR, "Container %q: %v", containerName, err)
+		}
+		if restartPolicy.Name != container.RESTART_NO && !containerConfig.Daemonized {
+			return TomlConfig{}, exit.Errorf(exit.CONFIG_ERROR, "Container %q: RESTART applies to daemonized containers only.Please correct.", containerName)
+		}
+	}
+
+	//Plain text passwords must not show up in the logs either
+	for _, containerConfig := range config.Containers {
+		if !secret.IsReference(containerConfig.Password) {
+			log.RegisterSecret(containerConfig.Password)
+		}
 	}
 
 	logger.Debug("Decoded config file:\n%v", config)
@@ -60,7 +119,7 @@ func ReadConfig() TomlConfig {
 
 	return TomlConfig{
 		CraneConfig: config,
-		CraneState:  state}
+		CraneState:  state}, nil
 }
 
 /*
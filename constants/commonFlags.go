@@ -1,11 +1,27 @@
 package constants
 
+import (
+	"time"
+)
+
 type CommonFlags struct {
 	DebugMode bool `short:"d" long:"debug" description:"When crane is used in the debug mode a lot of extra information is provided during program execution." `
 
+	LogLevel string `long:"log-level" env:"CRANE_LOG_LEVEL" description:"Log level: critical, error, warning, notice (default), info or debug."`
+
+	LogFormat string `long:"log-format" env:"CRANE_LOG_FORMAT" description:"Log format: text (default) or json (one JSON object per line with structured fields)."`
+
+	LogFile string `long:"log-file" env:"CRANE_LOG_FILE" description:"Additionally write logs into a given file."`
+
+	Syslog bool `long:"syslog" description:"Additionally send logs to the syslog."`
+
+	Output string `long:"output" env:"CRANE_OUTPUT" description:"Print the result of the command on the stdout in a machine readable format: json or yaml. Logs stay on the stderr."`
+
 	Version    bool `short:"v" long:"version" description:"Shows the information about the crane version you are using."`
 	ForceImage bool `short:"f" long:"force" description:"If chosen, crane will assume that the chosen image already exists in the host system(useful for offline mode)" `
 
+	Offline bool `long:"offline" env:"CRANE_OFFLINE" description:"Never reach image registries: images are taken from the host or built, PULL_POLICY always and if-missing act like never."`
+
 	All bool `short:"a" long:"all" description:"Performs operation for all available arguments"`
 
 	Save string `short:"s" long:"save" description:"Transforms(commits) containers into immutable images that can be reused in the future.For multiple containers provide comma separated names."`
@@ -14,7 +30,27 @@ type CommonFlags struct {
 
 	RunAllCommand string `short:"c" long:"commands" description:"To be used alongside runall.Run specified commands from the Cranefile across all containers"`
 
-	RunAllContainer string `short:"l" long: "containers" description:"To be used alongside runall command.Run all commands in specified containers"`
+	RunAllContainer string `short:"l" long:"containers" description:"To be used alongside runall command.Run all commands in specified containers"`
+
+	KeepGoing bool `short:"k" long:"keep-going" description:"Commands working on multiple containers carry on with the remaining containers when one of them fails."`
+
+	FailFast bool `long:"fail-fast" description:"Commands working on multiple containers stop at the first failing container (default)."`
+
+	Runtime string `long:"runtime" env:"CRANE_RUNTIME" description:"Container runtime used on this host: docker or podman. Overrides RUNTIME from the Cranefile."`
+
+	Escalation string `long:"escalation" env:"CRANE_ESCALATION" description:"How docker commands gain privileges: auto (default, sudo only if the docker socket is not accessible), none, sudo or custom."`
+
+	EscalationCommand string `long:"escalation-command" env:"CRANE_ESCALATION_COMMAND" description:"Wrapper command prepended to docker commands with the custom escalation (e.g. \"doas\" or \"sudo -n\")."`
+
+	DryRun bool `long:"dry-run" description:"Prints docker commands, ssh commands and state file changes instead of executing them."`
+
+	Timeout time.Duration `long:"timeout" env:"CRANE_TIMEOUT" description:"Stops crane and removes containers it created when it does not finish within a given time (e.g. 30s, 10m, 1h)."`
+
+	CommandTimeout time.Duration `long:"command-timeout" description:"Terminates every command executed inside a container (run, runall) that does not finish within a given time (e.g. 30s, 10m)."`
+
+	Volumes bool `long:"volumes" description:"To be used alongside destroy.Also removes named volumes crane created for destroyed containers."`
+
+	SuperviseInterval time.Duration `long:"interval" description:"How often crane supervise checks containers (e.g. 5s, 1m)."`
 
 	RunAllOwnCommand string `short:"o" long:"owncommands" description:"To be used alongside runall.Run specified own(not Cranefile) commands across all containers"`
 }
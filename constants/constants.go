@@ -23,7 +23,6 @@ const (
 
 const (
 	DOCKER       = "docker"
-	SUDO         = "sudo"
 	INSPECT      = "inspect"
 	KILL         = "kill"
 	REMOVE       = "rm"
@@ -33,6 +32,12 @@ const (
 	IMAGES       = "images"
 	REMOVE_IMAGE = "rmi"
 	COMMIT       = "commit"
+	COPY         = "cp"
+	EXEC         = "exec"
+	VOLUME       = "volume"
+	PORT         = "port"
+	FORMAT       = "--format="
+	FORCE        = "-f"
 	//"run","pull","create" are the same as for crane
 )
 
@@ -48,14 +53,35 @@ const (
 	SSHD_COMMAND        = "/usr/sbin/sshd -D"
 	SHELL_COMMAND       = "/bin/bash"
 	SHELL_STRING_OPTION = "-c"
+	NETCAT_COMMAND      = "nc"
 
 	GREP = "grep"
 
+	DEFAULT_TERMINAL        = "xterm"
+	DEFAULT_TERMINAL_WIDTH  = 80
+	DEFAULT_TERMINAL_HEIGHT = 40
+	TERMINAL_SPEED          = 14400
+
 	LOGGER_NAME = "crane"
 
 	CONFIGURATION_FILE = "Cranefile.toml"
 	STATE_FILE         = ".crane"
 	ID_FILE            = ".cidfile"
 
+	RESTART_HISTORY_FILE = ".crane_restarts"
+
 	NOT_DAEMONIZED_IP = "not_deamonized_has_no_ip"
 )
+
+/*
+  Secrets
+*/
+const (
+	SECRET_PREFIX = "secret:"
+	ENV_PREFIX    = "env:"
+	FILE_PREFIX   = "file:"
+
+	SECRETS_FILE                = ".crane_secrets"
+	SECRETS_FILE_VARIABLE       = "CRANE_SECRETS_FILE"
+	SECRETS_PASSPHRASE_VARIABLE = "CRANE_SECRETS_PASSPHRASE"
+)
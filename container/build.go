@@ -0,0 +1,123 @@
+package container
+
+import (
+	"github.com/SnowRipple/crane/exit"
+	"regexp"
+	"sort"
+)
+
+/*
+ Docker build options
+*/
+const (
+	FILE_OPTION      = "--file="
+	BUILD_ARG_OPTION = "--build-arg="
+	TARGET_OPTION    = "--target="
+	LABEL_OPTION     = "--label="
+	NO_CACHE_OPTION  = "--no-cache"
+	PULL_OPTION      = "--pull"
+)
+
+//Names of build arguments and label keys e.g. "VERSION" or "org.opencontainers.image.source".
+var buildKeyPattern = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_.-]*$`)
+
+//Image build settings of a container defined in the [containers.<name>.build] table of the Cranefile.
+type Build struct {
+	Context    string            //Directory sent to the daemon, DOCKERFILE of the container when not set
+	Dockerfile string            //Path to the Dockerfile, "<context>/Dockerfile" when not set
+	Args       map[string]string //Build arguments e.g. {VERSION = "1.2"}
+	Target     string            //Stage of a multi-stage Dockerfile
+	Labels     map[string]string //Labels of the built image
+	NoCache    bool              //Do not use cached layers
+	Pull       bool              //Always pull newer versions of base images
+}
+
+//Checks the build settings.
+func (build Build) Validate() error {
+
+	for _, values := range []map[string]string{build.Args, build.Labels} {
+		for key := range values {
+			if !buildKeyPattern.MatchString(key) {
+				return exit.Errorf(exit.CONFIG_ERROR, "Invalid build argument or label name %q.Please correct.", key)
+			}
+		}
+	}
+	return nil
+}
+
+//Returns the build settings of a container with paths resolved against the Cranefile directory.
+//Containers without a build table are built from their DOCKERFILE directory.
+func (container Container) ResolvedBuild() (Build, error) {
+
+	build := container.Build
+	if len(build.Context) == 0 {
+		build.Context = container.Dockerfile
+	}
+	if len(build.Context) == 0 {
+		return build, exit.Errorf(exit.CONFIG_ERROR, "Image %q has nothing to be built from.Please set DOCKERFILE or CONTEXT in the build table.", container.Image)
+	}
+
+	context, err := resolveHostPath(build.Context)
+	if err != nil {
+		return build, err
+	}
+	build.Context = context
+
+	if len(build.Dockerfile) > 0 {
+		dockerfile, err := resolveHostPath(build.Dockerfile)
+		if err != nil {
+			return build, err
+		}
+		build.Dockerfile = dockerfile
+	}
+	return build, nil
+}
+
+//Builds docker build options tagging the image, the context goes last.
+//Arguments and labels are sorted so the command is the same on every run.
+func (build Build) Options(imageName string) []string {
+
+	options := []string{BUILD_WITH_NAME_OPTION, imageName}
+
+	if len(build.Dockerfile) > 0 {
+		options = append(options, FILE_OPTION+build.Dockerfile)
+	}
+	for _, name := range sortedKeys(build.Args) {
+		options = append(options, BUILD_ARG_OPTION+name+"="+build.Args[name])
+	}
+	if len(build.Target) > 0 {
+		options = append(options, TARGET_OPTION+build.Target)
+	}
+	for _, name := range sortedKeys(build.Labels) {
+		options = append(options, LABEL_OPTION+name+"="+build.Labels[name])
+	}
+	if build.NoCache {
+		options = append(options, NO_CACHE_OPTION)
+	}
+	if build.Pull {
+		options = append(options, PULL_OPTION)
+	}
+	return append(options, build.Context)
+}
+
+//Returns a copy of the build settings with an extra image label.
+func (build Build) WithLabel(name, value string) Build {
+
+	labels := map[string]string{name: value}
+	for key, labelValue := range build.Labels {
+		labels[key] = labelValue
+	}
+	build.Labels = labels
+	return build
+}
+
+//Returns keys of a map in alphabetical order.
+func sortedKeys(values map[string]string) []string {
+
+	var keys []string
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
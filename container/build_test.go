@@ -0,0 +1,59 @@
+package container
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestBuildOptions(t *testing.T) {
+
+	workingDirectory, _ := os.Getwd()
+
+	containerConfig := Container{
+		Image:      "example/web",
+		Dockerfile: "./legacy",
+		Build: Build{
+			Context:    "./web",
+			Dockerfile: "./docker/web.Dockerfile",
+			Args:       map[string]string{"VERSION": "1.2", "GO_VERSION": "1.21"},
+			Target:     "release",
+			Labels:     map[string]string{"team": "web"},
+			NoCache:    true,
+			Pull:       true,
+		},
+	}
+
+	build, err := containerConfig.ResolvedBuild()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := []string{"-t", "example/web",
+		"--file=" + filepath.Join(workingDirectory, "docker/web.Dockerfile"),
+		"--build-arg=GO_VERSION=1.21", "--build-arg=VERSION=1.2",
+		"--target=release", "--label=team=web", "--no-cache", "--pull",
+		filepath.Join(workingDirectory, "web")}
+	if options := build.Options(containerConfig.Image); !reflect.DeepEqual(options, expected) {
+		t.Errorf("Options() = %q; expected %q", options, expected)
+	}
+}
+
+func TestResolvedBuildDefaultsToDockerfileDirectory(t *testing.T) {
+
+	build, err := Container{Image: "example/db", Dockerfile: "/srv/db"}.ResolvedBuild()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if options := build.Options("example/db"); !reflect.DeepEqual(options, []string{"-t", "example/db", "/srv/db"}) {
+		t.Errorf("Unexpected options %q", options)
+	}
+
+	if _, err := (Container{Image: "example/db"}).ResolvedBuild(); err == nil {
+		t.Errorf("Image without a context accepted")
+	}
+	if err := (Build{Args: map[string]string{"BAD KEY": "1"}}).Validate(); err == nil {
+		t.Errorf("Invalid build argument accepted")
+	}
+}
@@ -2,8 +2,10 @@ package container
 
 import (
 	"github.com/SnowRipple/crane/constants"
+	"github.com/SnowRipple/crane/exit"
 	ownLog "github.com/SnowRipple/crane/logger"
-	"strconv"
+	"github.com/SnowRipple/crane/terminal"
+	"github.com/SnowRipple/crane/x11"
 	"strings"
 )
 
@@ -14,14 +16,15 @@ const (
 	INTERACTIVE_OPTION     = "-i"
 	TTY_OPTION             = "-t"
 	VOLUME_OPTION          = "-v="
-	NAME_OPTION            = "-name="
-	DNS_OPTION             = "-dns="
+	NAME_OPTION            = "--name="
+	DNS_OPTION             = "--dns="
 	DAEMONIZED_OPTION      = "-d"
 	WORKING_DIR_OPTION     = "-w="
 	PORT_REDIRECT_OPTION   = "-p="
-	PRIVILEDGED_OPTION     = "-privileged"
+	PRIVILEDGED_OPTION     = "--privileged"
 	BUILD_WITH_NAME_OPTION = "-t"
-	CID_OPTION             = "-cidfile="
+	CID_OPTION             = "--cidfile="
+	ENV_OPTION             = "-e="
 
 	MOUNTPOINTS_ARGUMENT_COUNT = 3
 	PORTS_ARGUMENT_COUNT       = 2
@@ -32,17 +35,22 @@ var logger = ownLog.GetLogger()
 //Builds a docker run command used by:
 //->crane start - to start daemonized containers.
 //->crane enter,run and runall - to run the non-deamonized containers.
-func BuildRunCommand(container Container, containerName string, needsTTY, needsCidfile bool) []string {
+//The container is run by the daemon of a given host.
+func BuildRunCommand(host Host, container Container, containerName string, needsTTY, needsCidfile bool) ([]string, error) {
 
 	logger.Debug("Starting building run command...")
-	dockerCommand := []string{constants.DOCKER, constants.RUN}
+	dockerCommand := host.DockerCommand(constants.RUN)
 
 	//Closure to simplify append process
 	addCommandPart := func(command string) { dockerCommand = append(dockerCommand, command) }
 
 	//Add necessary options
 	addCommandPart(INTERACTIVE_OPTION)
-	addCommandPart(PRIVILEDGED_OPTION)
+
+	if container.Privileged {
+		addCommandPart(PRIVILEDGED_OPTION)
+		logger.Debug("Container %q runs in the privileged mode.", containerName)
+	}
 
 	if needsCidfile {
 		fileName := constants.ID_FILE + containerName
@@ -51,7 +59,7 @@ func BuildRunCommand(container Container, containerName string, needsTTY, needsC
 	}
 	//Set up DNS if needed
 	if len(strings.TrimSpace(container.Dns)) > 0 {
-		addCommandPart(DNS_OPTION + "[" + container.Dns + "]")
+		addCommandPart(DNS_OPTION + container.Dns)
 		logger.Debug("Using DNS:" + container.Dns + ".")
 	}
 
@@ -62,13 +70,26 @@ func BuildRunCommand(container Container, containerName string, needsTTY, needsC
 	}
 
 	//Ports redirection
-	if len(container.Ports) > 0 {
-		portsCommands := buildPortsCommands(container.Ports)
+	for _, portCommand := range container.Ports.Options() {
+		addCommandPart(portCommand)
+	}
 
-		for _, portCommand := range portsCommands {
-			addCommandPart(portCommand)
-		}
+	//Resource limits
+	resourcesOptions, err := container.Resources.Options()
+	if err != nil {
+		return nil, exit.Errorf(exit.Code(err), "Container %q: %v", containerName, err)
+	}
+	for _, resourcesOption := range resourcesOptions {
+		addCommandPart(resourcesOption)
+	}
 
+	//Security settings
+	securityOptions, err := container.Security.Options()
+	if err != nil {
+		return nil, exit.Errorf(exit.Code(err), "Container %q: %v", containerName, err)
+	}
+	for _, securityOption := range securityOptions {
+		addCommandPart(securityOption)
 	}
 
 	//Daemonized?
@@ -79,22 +100,52 @@ func BuildRunCommand(container Container, containerName string, needsTTY, needsC
 	if !container.Daemonized && needsTTY { //Allocate tty
 		addCommandPart(TTY_OPTION)
 		logger.Debug("Allocated tty.")
+
+		//Let the container know what kind of terminal it is attached to
+		addCommandPart(ENV_OPTION + "TERM=" + terminal.GetTerminalType())
 	}
 
 	//Mount external directories
 	if len(container.Mountpoints) > 0 {
 		logger.Debug("Mountpoints detected, extracting...")
-		mountpointCommands := buildMountpointCommands(container.Mountpoints)
+		mountpointCommands, err := buildMountpointCommands(host, container.Mountpoints)
+		if err != nil {
+			return nil, err
+		}
 
 		for _, mountpointCommand := range mountpointCommands {
 			addCommandPart(mountpointCommand)
 		}
 	}
 
+	//In-memory filesystems
+	if len(container.Tmpfs) > 0 {
+		tmpfsCommands, err := buildTmpfsCommands(container.Tmpfs)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, tmpfsCommand := range tmpfsCommands {
+			addCommandPart(tmpfsCommand)
+		}
+	}
+
+	//Open GUI windows on the local display.Daemonized containers get the display forwarded over ssh instead.
+	if container.Graphical && !container.Daemonized {
+		displayCommands, err := buildDisplayCommands(host, containerName)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, displayCommand := range displayCommands {
+			addCommandPart(displayCommand)
+		}
+	}
+
 	//Image takes precedence over the dockerfile
 
 	if len(strings.TrimSpace(container.Image)) == 0 {
-		logger.Fatal("No image was specified in the Cranefile.Please correct.")
+		return nil, exit.Errorf(exit.CONFIG_ERROR, "No image was specified in the Cranefile.Please correct.")
 	} else {
 		//Use existing image
 		addCommandPart(container.Image)
@@ -104,44 +155,60 @@ func BuildRunCommand(container Container, containerName string, needsTTY, needsC
 
 	logger.Debug("Final builded run command:\n%v", dockerCommand)
 
-	return dockerCommand
+	return dockerCommand, nil
 }
 
-//Parses through all mountpoints pairs provided in the Cranefile (if any) and builds docker volume option commands.
-func buildMountpointCommands(mountpoints [][]string) []string {
+//Parses through all mountpoints provided in the Cranefile (if any) and builds docker volume option commands.
+//Sources of bind mounts must exist, they can be checked only when the container runs on this machine.
+func buildMountpointCommands(host Host, mountpoints [][]string) ([]string, error) {
 
 	mountpointsCommands := []string{}
 
 	for index := 0; index < len(mountpoints); index++ {
-		mountpointPair := mountpoints[index]
-		logger.Debug("\n\nMountpointPair is %v", mountpointPair)
-		if len(mountpointPair) != MOUNTPOINTS_ARGUMENT_COUNT {
-			logger.Fatal("Wrong amount of mountpoints arguments specified for the pair nr " + strconv.Itoa(index) + " : Expected " + strconv.Itoa(MOUNTPOINTS_ARGUMENT_COUNT) + ", Actual " + strconv.Itoa(len(mountpointPair)) + ".Please correct")
+		logger.Debug("\n\nMountpoint is %v", mountpoints[index])
+		mountpoint, err := ParseMountpoint(mountpoints[index])
+		if err != nil {
+			return nil, err
 		}
 
-		logger.Debug("\nHost mountpoint is %v and corresponding remote will be placed in %v as a %s filesystem\n", mountpointPair[0], mountpointPair[1], mountpointPair[2])
-		mountpointsCommands = append(mountpointsCommands, VOLUME_OPTION+mountpointPair[0]+constants.COMMANDS_DELIMITER+mountpointPair[1]+constants.COMMANDS_DELIMITER+mountpointPair[2])
+		if host.IsLocal() {
+			if err := mountpoint.CheckSource(); err != nil {
+				return nil, err
+			}
+		}
+
+		logger.Debug("\nHost mountpoint is %v and corresponding remote will be placed in %v as a %s filesystem\n", mountpoint.Source, mountpoint.Target, mountpoint.Mode)
+		mountpointsCommands = append(mountpointsCommands, VOLUME_OPTION+mountpoint.Source+constants.COMMANDS_DELIMITER+mountpoint.Target+constants.COMMANDS_DELIMITER+mountpoint.Mode)
 
 	}
 
-	return mountpointsCommands
+	return mountpointsCommands, nil
 }
 
-//Parses through all ports pairs provided in the Cranefile and builds docker port option commands.
-func buildPortsCommands(ports [][]int) []string {
+//Builds docker options sharing the local X11 display with a graphical container.
+func buildDisplayCommands(host Host, containerName string) ([]string, error) {
 
-	portsCommands := []string{}
+	if !host.IsLocal() {
+		return nil, exit.Errorf(exit.CONFIG_ERROR, "Graphical container %q runs on a remote host so it can't use the local display.Please make it daemonized to get the display forwarded over ssh.", containerName)
+	}
 
-	for index := 0; index < len(ports); index++ {
-		portsPair := ports[index]
-		logger.Debug("\nPorts Pair is %v", portsPair)
-		if len(portsPair) != PORTS_ARGUMENT_COUNT {
-			logger.Fatal("Wrong amount of port arguments specified for the pair nr %d : Expected %d, Actual %d. Please correct", index, PORTS_ARGUMENT_COUNT, len(portsPair))
+	mountpoints, environment, err := x11.ContainerSetup()
+	if err != nil {
+		if _, displayErr := x11.Display(); displayErr != nil { //Headless machine, the container still runs
+			logger.Warning("Container %q is graphical but %v", containerName, displayErr)
+			return nil, nil
 		}
+		return nil, err
+	}
 
-		logger.Debug("\nPublic port is %d and the private port is %d", portsPair[0], portsPair[1])
-
-		portsCommands = append(portsCommands, PORT_REDIRECT_OPTION+strconv.Itoa(portsPair[0])+constants.COMMANDS_DELIMITER+strconv.Itoa(portsPair[1]))
+	displayCommands, err := buildMountpointCommands(host, mountpoints)
+	if err != nil {
+		return nil, err
+	}
+	for _, variable := range environment {
+		displayCommands = append(displayCommands, ENV_OPTION+variable)
 	}
-	return portsCommands
+	logger.Debug("Sharing the display with container %q: %v", containerName, displayCommands)
+
+	return displayCommands, nil
 }
@@ -4,29 +4,41 @@ import "fmt"
 
 //Model of a container defined in the Cranefile.
 type Container struct {
-	Image       string
-	Dockerfile  string
-	Graphical   bool
-	Daemonized  bool
-	Cwd         string
-	Dns         string
-	Password    string
-	Username    string
-	Ports       [][]int
-	Mountpoints [][]string
-	Commands    [][]string
+	Image          string
+	Dockerfile     string //Build context directory, see Build for more settings
+	Graphical      bool
+	Daemonized     bool
+	Privileged     bool //Full access to the host, only when explicitly asked for
+	Cwd            string
+	Dns            string
+	Password       string
+	Username       string
+	Ports          Ports      //[hostPort, containerPort] pairs or strings like "127.0.0.1:8080:80/tcp"
+	Mountpoints    [][]string //[hostPath or volumeName, containerPath, "ro" or "rw"]
+	Tmpfs          [][]string //[containerPath] or [containerPath, size]
+	Commands       [][]string
+	Host           string //Name of the docker host from the [hosts] section, empty for the local daemon
+	Build          Build
+	PullPolicy     string `toml:"PULL_POLICY"` //always, if-missing, never or build
+	Resources      Resources
+	Security       Security
+	Restart        string //Restart policy applied by crane supervise: no, always, on-failure or on-failure:<maxRetries>
+	RestartBackoff string //Delay before the first restart, doubled with every consecutive failure
 }
 
 func (container *Container) String() string {
-	return fmt.Sprintf("Image name: %s\nDockerfile: %s\nGraphical?: %t\nDaemonized?: %t\nWorking Directory: %s\nDNS: %%s\nPassword: %s\nUsername: %s\n,Ports: \n,%s\nMountpoints: \n%v\n,Commands:\n%v\n", container.Image, container.Dockerfile, container.Graphical, container.Daemonized, container.Cwd, container.Dns, container.Password, container.Username, container.Ports, container.Mountpoints, container.Commands)
+	return fmt.Sprintf("Image name: %s\nDockerfile: %s\nGraphical?: %t\nDaemonized?: %t\nWorking Directory: %s\nDNS: %s\nPassword: %s\nUsername: %s\n,Ports: \n,%v\nMountpoints: \n%v\n,Commands:\n%v\nResources: %s\n", container.Image, container.Dockerfile, container.Graphical, container.Daemonized, container.Cwd, container.Dns, container.Password, container.Username, container.Ports, container.Mountpoints, container.Commands, container.Resources)
 }
 
 //Model of a container defined in the .crane file.
 type StateContainer struct {
-	ID string
-	IP string
+	ID       string
+	IP       string
+	Host     string   //Name of the docker host owning the container, empty for the local daemon
+	Ports    []string //Ports published by the daemon e.g. "22/tcp -> 0.0.0.0:49153"
+	Snapshot string   //Image of the snapshot the container was rolled back to, empty for the Cranefile image
 }
 
 func (stateContainer *StateContainer) String() string {
-	return fmt.Sprintf("StateContainer ID: %s\nStateContainer IP: %s\n", stateContainer.ID, stateContainer.IP)
+	return fmt.Sprintf("StateContainer ID: %s\nStateContainer IP: %s\nStateContainer Host: %s\nStateContainer Ports: %v\n", stateContainer.ID, stateContainer.IP, stateContainer.Host, stateContainer.Ports)
 }
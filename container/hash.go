@@ -0,0 +1,140 @@
+package container
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"github.com/SnowRipple/crane/constants"
+	"github.com/SnowRipple/crane/exit"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+/*
+ Build sources hashing
+*/
+const (
+	CONTEXT_HASH_LABEL = "crane.context-hash" //Label of built images holding the hash of their sources
+	DOCKERIGNORE_FILE  = ".dockerignore"
+	DEFAULT_DOCKERFILE = "Dockerfile"
+	GIT_DIRECTORY      = ".git"
+)
+
+//Hashes everything an image is built from: files of the build context (without the ones .dockerignore excludes),
+//the Dockerfile and build arguments, target and labels.The same sources always give the same hash.
+//Crane's own files (Cranefile, state file etc.) and the .git directory do not change the image hence are left out.
+func (build Build) Hash() (string, error) {
+
+	ignorePatterns, err := readDockerignore(build.Context)
+	if err != nil {
+		return "", err
+	}
+
+	digest := sha256.New()
+
+	err = filepath.Walk(build.Context, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		relativePath, err := filepath.Rel(build.Context, path)
+		if err != nil || relativePath == "." {
+			return err
+		}
+		if isIgnoredSource(relativePath, ignorePatterns) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		return hashSource(digest, path, relativePath, info)
+	})
+	if err != nil {
+		return "", exit.Errorf(exit.FILE_ERROR, "Failed to read the build context %q: %v", build.Context, err)
+	}
+
+	if len(build.Dockerfile) > 0 { //May live outside the context
+		info, err := os.Stat(build.Dockerfile)
+		if err != nil {
+			return "", exit.Errorf(exit.FILE_ERROR, "Failed to read the Dockerfile %q: %v", build.Dockerfile, err)
+		}
+		if err := hashSource(digest, build.Dockerfile, DEFAULT_DOCKERFILE, info); err != nil {
+			return "", exit.Errorf(exit.FILE_ERROR, "Failed to read the Dockerfile %q: %v", build.Dockerfile, err)
+		}
+	}
+
+	settings := Build{Args: build.Args, Target: build.Target, Labels: build.Labels}
+	io.WriteString(digest, strings.Join(settings.Options(""), "\x00"))
+
+	return hex.EncodeToString(digest.Sum(nil)), nil
+}
+
+//Adds a single file, directory or symlink of the context to the hash.
+func hashSource(digest hash.Hash, path, relativePath string, info os.FileInfo) error {
+
+	io.WriteString(digest, filepath.ToSlash(relativePath)+"\x00"+info.Mode().String()+"\x00")
+
+	switch {
+	case info.Mode()&os.ModeSymlink != 0:
+		target, err := os.Readlink(path)
+		if err != nil {
+			return err
+		}
+		io.WriteString(digest, target)
+	case info.Mode().IsRegular():
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		if _, err := io.Copy(digest, file); err != nil {
+			return err
+		}
+	}
+	io.WriteString(digest, "\x00")
+	return nil
+}
+
+//Reads exclusion patterns of the context's .dockerignore.Exceptions ("!pattern") are not supported and skipped.
+func readDockerignore(context string) ([]string, error) {
+
+	file, err := os.Open(filepath.Join(context, DOCKERIGNORE_FILE))
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, exit.Errorf(exit.FILE_ERROR, "Failed to read %q: %v", DOCKERIGNORE_FILE, err)
+	}
+	defer file.Close()
+
+	var patterns []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		pattern := strings.TrimSpace(scanner.Text())
+		if len(pattern) == 0 || strings.HasPrefix(pattern, "#") || strings.HasPrefix(pattern, "!") {
+			continue
+		}
+		patterns = append(patterns, filepath.Clean(strings.TrimPrefix(pattern, "/")))
+	}
+	return patterns, scanner.Err()
+}
+
+//Checks if a context path does not influence the image.
+func isIgnoredSource(relativePath string, ignorePatterns []string) bool {
+
+	name := filepath.Base(relativePath)
+	if relativePath == name && (name == constants.CONFIGURATION_FILE || strings.HasPrefix(name, constants.STATE_FILE) || strings.HasPrefix(name, constants.ID_FILE)) {
+		return true //Crane's own files next to the Cranefile
+	}
+	if name == GIT_DIRECTORY {
+		return true
+	}
+
+	for _, pattern := range ignorePatterns {
+		if matched, _ := filepath.Match(pattern, relativePath); matched {
+			return true
+		}
+	}
+	return false
+}
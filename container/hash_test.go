@@ -0,0 +1,50 @@
+package container
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestBuildHash(t *testing.T) {
+
+	context, err := ioutil.TempDir("", "crane")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(context)
+
+	write := func(name, content string) {
+		if err := ioutil.WriteFile(filepath.Join(context, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	hash := func(build Build) string {
+		sourcesHash, err := build.Hash()
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		return sourcesHash
+	}
+
+	write("Dockerfile", "FROM busybox\n")
+	write(".dockerignore", "*.log\n")
+	build := Build{Context: context}
+	original := hash(build)
+
+	write(".crane", "[statecontainers]\n")
+	write("build.log", "ignored")
+	if hash(build) != original {
+		t.Errorf("Hash changed by files that are not sent to the daemon")
+	}
+
+	if hash(Build{Context: context, Args: map[string]string{"VERSION": "2"}}) == original {
+		t.Errorf("Hash not changed by build arguments")
+	}
+
+	write("Dockerfile", "FROM alpine\n")
+	if hash(build) == original {
+		t.Errorf("Hash not changed by the Dockerfile")
+	}
+}
@@ -0,0 +1,86 @@
+package container
+
+import (
+	"github.com/SnowRipple/crane/constants"
+	"net/url"
+	"strings"
+)
+
+/*
+ Docker options selecting the daemon
+*/
+const (
+	HOST_OPTION        = "-H="
+	TLS_VERIFY_OPTION  = "--tlsverify"
+	TLS_CA_CERT_OPTION = "--tlscacert="
+	TLS_CERT_OPTION    = "--tlscert="
+	TLS_KEY_OPTION     = "--tlskey="
+
+	UNIX_ADDRESS_PREFIX = "unix://"
+	TCP_ADDRESS_PREFIX  = "tcp://"
+	SSH_ADDRESS_PREFIX  = "ssh://"
+
+	LOCAL_HOSTNAME = "127.0.0.1"
+)
+
+//Model of a docker daemon defined in the [hosts] section of the Cranefile.
+type Host struct {
+	Address   string //unix:///path/to/docker.sock, tcp://host:port or ssh://user@host
+	Tls       bool   //Verify the daemon's certificate (tcp only)
+	TlsCaCert string
+	TlsCert   string
+	TlsKey    string
+}
+
+//Builds a docker command executed by the host's daemon.The local daemon is used when the host has no address.
+func (host Host) DockerCommand(arguments ...string) []string {
+
+	command := []string{constants.DOCKER}
+
+	if len(host.Address) > 0 {
+		command = append(command, HOST_OPTION+host.Address)
+	}
+
+	if host.Tls {
+		command = append(command, TLS_VERIFY_OPTION)
+		if len(host.TlsCaCert) > 0 {
+			command = append(command, TLS_CA_CERT_OPTION+host.TlsCaCert)
+		}
+		if len(host.TlsCert) > 0 {
+			command = append(command, TLS_CERT_OPTION+host.TlsCert)
+		}
+		if len(host.TlsKey) > 0 {
+			command = append(command, TLS_KEY_OPTION+host.TlsKey)
+		}
+	}
+
+	return append(command, arguments...)
+}
+
+//Returns true if the address is a unix socket, a tcp endpoint or an ssh tunnel.
+func (host Host) IsValidAddress() bool {
+
+	for _, prefix := range []string{UNIX_ADDRESS_PREFIX, TCP_ADDRESS_PREFIX, SSH_ADDRESS_PREFIX} {
+		if strings.HasPrefix(host.Address, prefix) && len(host.Address) > len(prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+//Returns true if the daemon runs on this machine (local socket).
+func (host Host) IsLocal() bool {
+	return len(host.Address) == 0 || strings.HasPrefix(host.Address, UNIX_ADDRESS_PREFIX)
+}
+
+//Returns the name of the machine the daemon runs on, e.g. "build.example.com" for "ssh://deploy@build.example.com".
+func (host Host) Hostname() string {
+
+	if host.IsLocal() {
+		return LOCAL_HOSTNAME
+	}
+	if address, err := url.Parse(host.Address); err == nil && len(address.Hostname()) > 0 {
+		return address.Hostname()
+	}
+	return LOCAL_HOSTNAME
+}
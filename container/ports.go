@@ -0,0 +1,354 @@
+package container
+
+import (
+	"errors"
+	"fmt"
+	"github.com/SnowRipple/crane/constants"
+	"github.com/SnowRipple/crane/exit"
+	"net"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+/*
+ Published ports
+*/
+const (
+	PROTOCOL_TCP  = "tcp"
+	PROTOCOL_UDP  = "udp"
+	PROTOCOL_SCTP = "sctp"
+
+	PROTOCOL_DELIMITER = "/"
+	RANGE_DELIMITER    = "-"
+	PUBLISHED_ARROW    = " -> "
+
+	MIN_PORT  = 1
+	MAX_PORT  = 65535
+	AUTO_PORT = 0 //The host chooses a free port
+
+	SSH_CONTAINER_PORT = "22/" + PROTOCOL_TCP
+)
+
+//Single entry of PORTS: [hostPort, containerPort], [containerPort] or a string like "127.0.0.1:8080:80/tcp", "53:53/udp", "9000-9002:9000-9002" or "80".
+type Port struct {
+	HostIP        string
+	HostPort      string //Port or range, empty when the host chooses a free port
+	ContainerPort string //Port or range
+	Protocol      string
+}
+
+//All ports published by a container.
+type Ports []Port
+
+//Decodes PORTS from the Cranefile where integer pairs and strings can be mixed.
+func (ports *Ports) UnmarshalTOML(data interface{}) error {
+
+	entries, ok := data.([]interface{})
+	if !ok {
+		return exit.Errorf(exit.CONFIG_ERROR, "PORTS must be an array, got %v.Please correct.", data)
+	}
+
+	for index, entry := range entries {
+		port, err := parsePortEntry(entry)
+		if err != nil {
+			return exit.Errorf(exit.CONFIG_ERROR, "Invalid port nr %d %v: %v", index, entry, err)
+		}
+		*ports = append(*ports, port)
+	}
+	return nil
+}
+
+//Parses a single PORTS entry.
+func parsePortEntry(entry interface{}) (Port, error) {
+
+	switch value := entry.(type) {
+	case string:
+		return ParsePort(value)
+	case []interface{}:
+		var numbers []string
+		for _, number := range value {
+			integer, ok := number.(int64)
+			if !ok {
+				return Port{}, fmt.Errorf("port pairs must contain integers")
+			}
+			numbers = append(numbers, strconv.FormatInt(integer, 10))
+		}
+		switch len(numbers) {
+		case 1:
+			return ParsePort(numbers[0])
+		case PORTS_ARGUMENT_COUNT:
+			if numbers[0] == strconv.Itoa(AUTO_PORT) {
+				return ParsePort(numbers[1])
+			}
+			return ParsePort(numbers[0] + constants.COMMANDS_DELIMITER + numbers[1])
+		}
+		return Port{}, fmt.Errorf("expected [hostPort, containerPort] or [containerPort]")
+	}
+	return Port{}, fmt.Errorf("expected a [hostPort, containerPort] pair or a string like \"127.0.0.1:8080:80/tcp\"")
+}
+
+//Parses a port in the docker syntax [hostIP:][hostPort:]containerPort[/protocol] where ports can be ranges (e.g. "9000-9002").
+func ParsePort(specification string) (Port, error) {
+
+	port := Port{Protocol: PROTOCOL_TCP}
+
+	if separator := strings.LastIndex(specification, PROTOCOL_DELIMITER); separator >= 0 {
+		port.Protocol = specification[separator+1:]
+		specification = specification[:separator]
+	}
+	switch port.Protocol {
+	case PROTOCOL_TCP, PROTOCOL_UDP, PROTOCOL_SCTP:
+	default:
+		return port, fmt.Errorf("unknown protocol %q, please use %s, %s or %s", port.Protocol, PROTOCOL_TCP, PROTOCOL_UDP, PROTOCOL_SCTP)
+	}
+
+	if strings.HasPrefix(specification, "[") { //IPv6 address e.g. "[::1]:8080:80"
+		closing := strings.Index(specification, "]")
+		if closing < 0 || !strings.HasPrefix(specification[closing+1:], constants.COMMANDS_DELIMITER) {
+			return port, fmt.Errorf("invalid IPv6 address in %q", specification)
+		}
+		port.HostIP = specification[1:closing]
+		specification = specification[closing+2:]
+	} else if parts := strings.Split(specification, constants.COMMANDS_DELIMITER); len(parts) == 3 {
+		port.HostIP = parts[0]
+		specification = parts[1] + constants.COMMANDS_DELIMITER + parts[2]
+	}
+
+	parts := strings.Split(specification, constants.COMMANDS_DELIMITER)
+	switch {
+	case len(parts) == 1 && len(port.HostIP) == 0:
+		port.ContainerPort = parts[0]
+	case len(parts) == 2:
+		port.HostPort, port.ContainerPort = parts[0], parts[1]
+	default:
+		return port, fmt.Errorf("expected [hostIP:][hostPort:]containerPort[/protocol]")
+	}
+
+	if len(port.HostIP) > 0 && net.ParseIP(port.HostIP) == nil {
+		return port, fmt.Errorf("invalid host IP %q", port.HostIP)
+	}
+
+	containerFirst, containerLast, err := parsePortRange(port.ContainerPort)
+	if err != nil {
+		return port, err
+	}
+	if len(port.HostPort) > 0 {
+		hostFirst, hostLast, err := parsePortRange(port.HostPort)
+		if err != nil {
+			return port, err
+		}
+		if containerFirst != containerLast && hostLast-hostFirst != containerLast-containerFirst {
+			return port, fmt.Errorf("host range %q and container range %q differ in size", port.HostPort, port.ContainerPort)
+		}
+	}
+	return port, nil
+}
+
+//Parses "80" or "9000-9002".
+func parsePortRange(portRange string) (first, last int, err error) {
+
+	bounds := strings.SplitN(portRange, RANGE_DELIMITER, 2)
+
+	if first, err = strconv.Atoi(bounds[0]); err != nil || first < MIN_PORT || first > MAX_PORT {
+		return 0, 0, fmt.Errorf("invalid port %q, ports are numbers between %d and %d", portRange, MIN_PORT, MAX_PORT)
+	}
+	last = first
+	if len(bounds) == 2 {
+		if last, err = strconv.Atoi(bounds[1]); err != nil || last < first || last > MAX_PORT {
+			return 0, 0, fmt.Errorf("invalid port range %q", portRange)
+		}
+	}
+	return first, last, nil
+}
+
+//Returns the port in the docker syntax, e.g. "127.0.0.1::80/udp".
+func (port Port) String() string {
+
+	specification := port.ContainerPort + PROTOCOL_DELIMITER + port.Protocol
+	if len(port.HostIP) > 0 {
+		hostIP := port.HostIP
+		if strings.Contains(hostIP, constants.COMMANDS_DELIMITER) {
+			hostIP = "[" + hostIP + "]"
+		}
+		return hostIP + constants.COMMANDS_DELIMITER + port.HostPort + constants.COMMANDS_DELIMITER + specification
+	}
+	if len(port.HostPort) > 0 {
+		return port.HostPort + constants.COMMANDS_DELIMITER + specification
+	}
+	return specification
+}
+
+//Builds docker options publishing the ports.
+func (ports Ports) Options() []string {
+
+	var options []string
+	for _, port := range ports {
+		options = append(options, PORT_REDIRECT_OPTION+port.String())
+	}
+	return options
+}
+
+//Parses the output of "docker port" (e.g. "22/tcp -> 0.0.0.0:49153") into "<containerPort>/<protocol> -> <hostIP>:<hostPort>" entries.
+func ParsePublishedPorts(output string) []string {
+
+	var published []string
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if strings.Contains(line, PUBLISHED_ARROW) {
+			published = append(published, line)
+		}
+	}
+	return published
+}
+
+//Returns the address a container port was published at, e.g. "0.0.0.0:49153".
+func (stateContainer StateContainer) PublishedAddress(containerPort string) (string, bool) {
+
+	for _, published := range stateContainer.Ports {
+		parts := strings.SplitN(published, PUBLISHED_ARROW, 2)
+		if len(parts) == 2 && parts[0] == containerPort {
+			return parts[1], true
+		}
+	}
+	return "", false
+}
+
+//Returns the address of the sshd inside a daemonized container.
+//The container IP is used when it can be reached, otherwise (remote host or no IP) the published ssh port.
+func (stateContainer StateContainer) SshAddress(host Host) string {
+
+	published, exists := stateContainer.PublishedAddress(SSH_CONTAINER_PORT)
+	reachable := host.IsLocal() && len(stateContainer.IP) > 0
+	if reachable || !exists {
+		return stateContainer.IP
+	}
+
+	hostIP, hostPort, err := net.SplitHostPort(published)
+	if err != nil {
+		return stateContainer.IP
+	}
+	if ip := net.ParseIP(hostIP); ip == nil || ip.IsUnspecified() { //Published on all interfaces of the docker host
+		hostIP = host.Hostname()
+	}
+	return net.JoinHostPort(hostIP, hostPort)
+}
+
+//Single host port a container publishes at.
+type HostPortClaim struct {
+	Container    string
+	Host         string //Name of the docker host, empty for the local daemon
+	HostIP       string //Empty for all interfaces
+	HostPort     int
+	LastHostPort int //A single container port published at a host range takes any one free port of HostPort-LastHostPort
+	Protocol     string
+}
+
+//Returns the host ports the ports are published at.Auto-assigned ports claim nothing as the daemon picks free ones.
+//A single container port published at a host range (e.g. "8000-8010:80") makes a single flexible claim.
+func (ports Ports) Claims(containerName, host string) []HostPortClaim {
+
+	var claims []HostPortClaim
+	for _, port := range ports {
+		if len(port.HostPort) == 0 {
+			continue
+		}
+		first, last, err := parsePortRange(port.HostPort)
+		if err != nil {
+			continue //Reported by the Cranefile validation
+		}
+		claim := HostPortClaim{Container: containerName, Host: host, HostIP: port.HostIP, Protocol: port.Protocol}
+		if !strings.Contains(port.ContainerPort, RANGE_DELIMITER) {
+			claim.HostPort, claim.LastHostPort = first, last
+			claims = append(claims, claim)
+			continue
+		}
+		for hostPort := first; hostPort <= last; hostPort++ {
+			claims = append(claims, claim.At(hostPort))
+		}
+	}
+	return claims
+}
+
+//Checks if the claim takes any one port of a range.
+func (claim HostPortClaim) IsFlexible() bool {
+	return claim.LastHostPort > claim.HostPort
+}
+
+//Returns the claim of a single host port.
+func (claim HostPortClaim) At(hostPort int) HostPortClaim {
+
+	claim.HostPort, claim.LastHostPort = hostPort, hostPort
+	return claim
+}
+
+//Checks if two claims can't be published at the same time: same daemon, protocol and port on overlapping interfaces.
+//Flexible claims are compared by their first port, pick a port with At first.
+func (claim HostPortClaim) Conflicts(other HostPortClaim) bool {
+
+	if claim.Host != other.Host || claim.Protocol != other.Protocol || claim.HostPort != other.HostPort {
+		return false
+	}
+	return isAllInterfaces(claim.HostIP) || isAllInterfaces(other.HostIP) || net.ParseIP(claim.HostIP).Equal(net.ParseIP(other.HostIP))
+}
+
+//Checks if the port is already bound on this machine by trying to bind it.Ports of protocols that can't be bound here are assumed free.
+func (claim HostPortClaim) IsBound() bool {
+
+	address := net.JoinHostPort(claim.HostIP, strconv.Itoa(claim.HostPort))
+
+	var err error
+	switch claim.Protocol {
+	case PROTOCOL_TCP:
+		var listener net.Listener
+		if listener, err = net.Listen(PROTOCOL_TCP, address); err == nil {
+			listener.Close()
+		}
+	case PROTOCOL_UDP:
+		var connection net.PacketConn
+		if connection, err = net.ListenPacket(PROTOCOL_UDP, address); err == nil {
+			connection.Close()
+		}
+	}
+	return errors.Is(err, syscall.EADDRINUSE) //Other errors (e.g. privileged ports) are left to the daemon
+}
+
+//Returns the claim as e.g. "127.0.0.1:8080/tcp", "8080/udp" or "8000-8010/tcp".
+func (claim HostPortClaim) String() string {
+
+	specification := strconv.Itoa(claim.HostPort)
+	if claim.IsFlexible() {
+		specification += RANGE_DELIMITER + strconv.Itoa(claim.LastHostPort)
+	}
+	specification += PROTOCOL_DELIMITER + claim.Protocol
+	if isAllInterfaces(claim.HostIP) {
+		return specification
+	}
+	return net.JoinHostPort(claim.HostIP, specification)
+}
+
+//Checks if a host IP stands for all interfaces of the host.
+func isAllInterfaces(hostIP string) bool {
+
+	ip := net.ParseIP(hostIP)
+	return len(hostIP) == 0 || (ip != nil && ip.IsUnspecified())
+}
+
+//Checks if the container published one of its ports at the claimed host port.
+func (stateContainer StateContainer) Publishes(claim HostPortClaim) bool {
+
+	for _, published := range stateContainer.Ports {
+		parts := strings.SplitN(published, PUBLISHED_ARROW, 2)
+		if len(parts) != 2 || !strings.HasSuffix(parts[0], PROTOCOL_DELIMITER+claim.Protocol) {
+			continue
+		}
+		hostIP, hostPort, err := net.SplitHostPort(parts[1])
+		if err != nil || hostPort != strconv.Itoa(claim.HostPort) {
+			continue
+		}
+		if claim.Conflicts(HostPortClaim{Host: stateContainer.Host, HostIP: hostIP, HostPort: claim.HostPort, Protocol: claim.Protocol}) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,120 @@
+package container
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParsePort(t *testing.T) {
+
+	cases := []struct {
+		specification string
+		expected      Port
+		formatted     string
+	}{
+		{"80", Port{ContainerPort: "80", Protocol: "tcp"}, "80/tcp"},
+		{"8080:80", Port{HostPort: "8080", ContainerPort: "80", Protocol: "tcp"}, "8080:80/tcp"},
+		{"53:53/udp", Port{HostPort: "53", ContainerPort: "53", Protocol: "udp"}, "53:53/udp"},
+		{"127.0.0.1:8080:80", Port{HostIP: "127.0.0.1", HostPort: "8080", ContainerPort: "80", Protocol: "tcp"}, "127.0.0.1:8080:80/tcp"},
+		{"127.0.0.1::5432", Port{HostIP: "127.0.0.1", ContainerPort: "5432", Protocol: "tcp"}, "127.0.0.1::5432/tcp"},
+		{"[::1]:8443:443", Port{HostIP: "::1", HostPort: "8443", ContainerPort: "443", Protocol: "tcp"}, "[::1]:8443:443/tcp"},
+		{"9000-9002:9000-9002", Port{HostPort: "9000-9002", ContainerPort: "9000-9002", Protocol: "tcp"}, "9000-9002:9000-9002/tcp"},
+	}
+
+	for _, testCase := range cases {
+		port, err := ParsePort(testCase.specification)
+		if err != nil {
+			t.Fatalf("Unexpected error for %q: %v", testCase.specification, err)
+		}
+		if port != testCase.expected {
+			t.Errorf("ParsePort(%q) = %+v; expected %+v", testCase.specification, port, testCase.expected)
+		}
+		if port.String() != testCase.formatted {
+			t.Errorf("%+v formatted as %q; expected %q", port, port.String(), testCase.formatted)
+		}
+	}
+
+	for _, invalid := range []string{"", "http", "70000", "80/icmp", "localhost:8080:80", "9000-9002:9000-9001", "1:2:3:4"} {
+		if _, err := ParsePort(invalid); err == nil {
+			t.Errorf("Invalid port %q accepted", invalid)
+		}
+	}
+}
+
+func TestUnmarshalPorts(t *testing.T) {
+
+	var ports Ports
+	err := ports.UnmarshalTOML([]interface{}{
+		[]interface{}{int64(49153), int64(22)},
+		[]interface{}{int64(0), int64(80)},
+		"127.0.0.1:5353:53/udp",
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := []string{"-p=49153:22/tcp", "-p=80/tcp", "-p=127.0.0.1:5353:53/udp"}
+	if !reflect.DeepEqual(ports.Options(), expected) {
+		t.Errorf("Options() = %q; expected %q", ports.Options(), expected)
+	}
+
+	if err := ports.UnmarshalTOML([]interface{}{[]interface{}{int64(1), int64(2), int64(3)}}); err == nil {
+		t.Errorf("Port triple accepted")
+	}
+}
+
+func TestSshAddress(t *testing.T) {
+
+	published := ParsePublishedPorts("22/tcp -> 0.0.0.0:49153\n80/tcp -> 127.0.0.1:8080\n")
+	stateContainer := StateContainer{ID: "1", IP: "172.17.0.2", Ports: published}
+
+	if address := stateContainer.SshAddress(Host{}); address != "172.17.0.2" {
+		t.Errorf("Local container reached at %q", address)
+	}
+	if address := stateContainer.SshAddress(Host{Address: "ssh://deploy@build.example.com"}); address != "build.example.com:49153" {
+		t.Errorf("Remote container reached at %q", address)
+	}
+
+	stateContainer.IP = ""
+	if address := stateContainer.SshAddress(Host{}); address != "127.0.0.1:49153" {
+		t.Errorf("Container without an IP reached at %q", address)
+	}
+}
+
+func TestHostPortClaimConflicts(t *testing.T) {
+
+	ports := Ports{{HostPort: "9000-9002", ContainerPort: "9000-9002", Protocol: "tcp"}, {ContainerPort: "80", Protocol: "tcp"}}
+	claims := ports.Claims("web", "")
+	if len(claims) != 3 || claims[2].HostPort != 9002 {
+		t.Fatalf("Unexpected claims: %v", claims)
+	}
+
+	cases := []struct {
+		other     HostPortClaim
+		conflicts bool
+	}{
+		{HostPortClaim{HostPort: 9001, Protocol: "tcp"}, true},
+		{HostPortClaim{HostIP: "127.0.0.1", HostPort: 9001, Protocol: "tcp"}, true},
+		{HostPortClaim{HostPort: 9001, Protocol: "udp"}, false},
+		{HostPortClaim{HostPort: 9003, Protocol: "tcp"}, false},
+		{HostPortClaim{Host: "build", HostPort: 9001, Protocol: "tcp"}, false},
+	}
+	for _, testCase := range cases {
+		if claims[1].Conflicts(testCase.other) != testCase.conflicts {
+			t.Errorf("%v conflicts with %v: expected %t", claims[1], testCase.other, testCase.conflicts)
+		}
+	}
+
+	flexible := Ports{{HostPort: "8000-8010", ContainerPort: "80", Protocol: "tcp"}}.Claims("web", "")
+	if len(flexible) != 1 || !flexible[0].IsFlexible() || flexible[0].String() != "8000-8010/tcp" {
+		t.Errorf("A single port published at a range claims one port of it: %v", flexible)
+	}
+
+	local := HostPortClaim{HostIP: "127.0.0.1", HostPort: 8080, Protocol: "tcp"}
+	if local.Conflicts(HostPortClaim{HostIP: "127.0.0.2", HostPort: 8080, Protocol: "tcp"}) {
+		t.Errorf("Ports on different interfaces conflict")
+	}
+	if !(StateContainer{Ports: []string{"80/tcp -> 0.0.0.0:8080"}}).Publishes(local) {
+		t.Errorf("Published port not recognised")
+	}
+}
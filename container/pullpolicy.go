@@ -0,0 +1,47 @@
+package container
+
+import (
+	"github.com/SnowRipple/crane/exit"
+)
+
+/*
+ Image pull policies
+*/
+const (
+	PULL_ALWAYS     = "always"     //Pull the image before every start, run and enter
+	PULL_IF_MISSING = "if-missing" //Use the image on the host, pull it only when it is missing
+	PULL_NEVER      = "never"      //Use the image on the host only
+	PULL_BUILD      = "build"      //Build the image from its sources, rebuild when they change
+)
+
+//Checks that a pull policy is known, empty means the default.
+func ValidatePullPolicy(policy string) error {
+
+	switch policy {
+	case "", PULL_ALWAYS, PULL_IF_MISSING, PULL_NEVER, PULL_BUILD:
+		return nil
+	}
+	return exit.Errorf(exit.CONFIG_ERROR, "Unknown PULL_POLICY %q.Please use %q, %q, %q or %q.", policy, PULL_ALWAYS, PULL_IF_MISSING, PULL_NEVER, PULL_BUILD)
+}
+
+//Returns the pull policy of a container: its own PULL_POLICY, otherwise the project's one,
+//otherwise "build" for containers with a DOCKERFILE and "if-missing" for the others.
+func (container Container) EffectivePullPolicy(projectPolicy string) string {
+
+	switch {
+	case len(container.PullPolicy) > 0:
+		return container.PullPolicy
+	case projectPolicy == PULL_BUILD && !container.HasBuildSources(): //Project wide "build" applies to containers that can be built
+		return PULL_IF_MISSING
+	case len(projectPolicy) > 0:
+		return projectPolicy
+	case container.HasBuildSources():
+		return PULL_BUILD
+	}
+	return PULL_IF_MISSING
+}
+
+//Checks if the container's image is built from sources listed in the Cranefile.
+func (container Container) HasBuildSources() bool {
+	return len(container.Dockerfile) > 0 || len(container.Build.Context) > 0
+}
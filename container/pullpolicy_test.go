@@ -0,0 +1,33 @@
+package container
+
+import (
+	"testing"
+)
+
+func TestEffectivePullPolicy(t *testing.T) {
+
+	built := Container{Image: "example/web", Dockerfile: "."}
+	pulled := Container{Image: "postgres"}
+
+	cases := []struct {
+		container     Container
+		projectPolicy string
+		expected      string
+	}{
+		{built, "", PULL_BUILD},
+		{pulled, "", PULL_IF_MISSING},
+		{pulled, PULL_NEVER, PULL_NEVER},
+		{pulled, PULL_BUILD, PULL_IF_MISSING},
+		{Container{Image: "postgres", PullPolicy: PULL_ALWAYS}, PULL_NEVER, PULL_ALWAYS},
+	}
+
+	for _, testCase := range cases {
+		if policy := testCase.container.EffectivePullPolicy(testCase.projectPolicy); policy != testCase.expected {
+			t.Errorf("Pull policy of %q with project policy %q is %q; expected %q", testCase.container.Image, testCase.projectPolicy, policy, testCase.expected)
+		}
+	}
+
+	if err := ValidatePullPolicy("sometimes"); err == nil {
+		t.Errorf("Unknown pull policy accepted")
+	}
+}
@@ -0,0 +1,128 @@
+package container
+
+import (
+	"github.com/SnowRipple/crane/exit"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+/*
+ Docker options limiting resources of a container
+*/
+const (
+	MEMORY_OPTION      = "--memory="
+	MEMORY_SWAP_OPTION = "--memory-swap="
+	CPUS_OPTION        = "--cpus="
+	CPU_SHARES_OPTION  = "--cpu-shares="
+	CPU_QUOTA_OPTION   = "--cpu-quota="
+	PIDS_LIMIT_OPTION  = "--pids-limit="
+	ULIMIT_OPTION      = "--ulimit="
+
+	UNLIMITED_SWAP = "-1"
+)
+
+//Sizes like "512m" or "2g" (bytes, kilobytes, megabytes or gigabytes).
+var sizePattern = regexp.MustCompile(`^[0-9]+[bkmgBKMG]?$`)
+
+//Ulimits like "nofile=1024" or "nofile=1024:2048" (soft:hard).
+var ulimitPattern = regexp.MustCompile(`^[a-z]+=-?[0-9]+(:-?[0-9]+)?$`)
+
+//Resource limits of a container defined in the [containers.<name>.resources] table of the Cranefile.
+type Resources struct {
+	Memory     string   //Memory limit e.g. "512m"
+	MemorySwap string   //Memory plus swap limit e.g. "1g", "-1" for unlimited swap
+	Cpus       string   //Number of CPUs e.g. "1.5"
+	CpuShares  int      //Relative CPU weight (1024 by default)
+	CpuQuota   int      //CPU time in microseconds per 100ms period
+	Pids       int      //Maximum number of processes
+	Ulimits    []string //e.g. ["nofile=1024:2048", "nproc=512"]
+}
+
+//Checks the units and ranges of configured limits.
+func (resources Resources) Validate() error {
+
+	if len(resources.Memory) > 0 && !sizePattern.MatchString(resources.Memory) {
+		return exit.Errorf(exit.CONFIG_ERROR, "Invalid MEMORY %q.Please use a number with an optional unit b, k, m or g (e.g. \"512m\").", resources.Memory)
+	}
+	if len(resources.MemorySwap) > 0 && resources.MemorySwap != UNLIMITED_SWAP && !sizePattern.MatchString(resources.MemorySwap) {
+		return exit.Errorf(exit.CONFIG_ERROR, "Invalid MEMORYSWAP %q.Please use a number with an optional unit b, k, m or g (e.g. \"1g\") or %q for unlimited swap.", resources.MemorySwap, UNLIMITED_SWAP)
+	}
+	if len(resources.MemorySwap) > 0 && len(resources.Memory) == 0 {
+		return exit.Errorf(exit.CONFIG_ERROR, "MEMORYSWAP can only be set together with MEMORY.Please correct.")
+	}
+	if len(resources.Cpus) > 0 {
+		if cpus, err := strconv.ParseFloat(resources.Cpus, 64); err != nil || cpus <= 0 {
+			return exit.Errorf(exit.CONFIG_ERROR, "Invalid CPUS %q.Please use a positive number (e.g. \"1.5\").", resources.Cpus)
+		}
+	}
+	if resources.CpuShares < 0 || resources.CpuQuota < 0 || resources.Pids < 0 {
+		return exit.Errorf(exit.CONFIG_ERROR, "CPUSHARES, CPUQUOTA and PIDS must not be negative.Please correct.")
+	}
+	for _, ulimit := range resources.Ulimits {
+		if !ulimitPattern.MatchString(ulimit) {
+			return exit.Errorf(exit.CONFIG_ERROR, "Invalid ULIMITS entry %q.Please use <name>=<soft>[:<hard>] (e.g. \"nofile=1024:2048\").", ulimit)
+		}
+	}
+	return nil
+}
+
+//Builds docker options enforcing configured limits.
+func (resources Resources) Options() ([]string, error) {
+
+	if err := resources.Validate(); err != nil {
+		return nil, err
+	}
+
+	var options []string
+	for _, limit := range resources.limits() {
+		options = append(options, limit.option+limit.value)
+	}
+	for _, ulimit := range resources.Ulimits {
+		options = append(options, ULIMIT_OPTION+ulimit)
+	}
+	return options, nil
+}
+
+//Summary of configured limits (e.g. "memory=512m cpus=1.5"), empty when the container is not limited.
+func (resources Resources) String() string {
+
+	var summary []string
+	for _, limit := range resources.limits() {
+		summary = append(summary, limit.name+"="+limit.value)
+	}
+	for _, ulimit := range resources.Ulimits {
+		summary = append(summary, "ulimit:"+ulimit)
+	}
+	return strings.Join(summary, " ")
+}
+
+type resourceLimit struct {
+	name   string
+	option string
+	value  string
+}
+
+//Configured limits in a stable order.
+func (resources Resources) limits() []resourceLimit {
+
+	var limits []resourceLimit
+	addLimit := func(name, option, value string) {
+		if len(value) > 0 {
+			limits = append(limits, resourceLimit{name: name, option: option, value: value})
+		}
+	}
+	addNumericLimit := func(name, option string, value int) {
+		if value > 0 {
+			addLimit(name, option, strconv.Itoa(value))
+		}
+	}
+
+	addLimit("memory", MEMORY_OPTION, strings.ToLower(resources.Memory))
+	addLimit("memory-swap", MEMORY_SWAP_OPTION, strings.ToLower(resources.MemorySwap))
+	addLimit("cpus", CPUS_OPTION, resources.Cpus)
+	addNumericLimit("cpu-shares", CPU_SHARES_OPTION, resources.CpuShares)
+	addNumericLimit("cpu-quota", CPU_QUOTA_OPTION, resources.CpuQuota)
+	addNumericLimit("pids", PIDS_LIMIT_OPTION, resources.Pids)
+	return limits
+}
@@ -0,0 +1,45 @@
+package container
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestResourcesOptions(t *testing.T) {
+
+	resources := Resources{Memory: "512M", Cpus: "1.5", Pids: 100, Ulimits: []string{"nofile=1024:2048"}}
+	expected := []string{"--memory=512m", "--cpus=1.5", "--pids-limit=100", "--ulimit=nofile=1024:2048"}
+
+	options, err := resources.Options()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(options, expected) {
+		t.Errorf("Options() = %q; expected %q", options, expected)
+	}
+	if summary := resources.String(); summary != "memory=512m cpus=1.5 pids=100 ulimit:nofile=1024:2048" {
+		t.Errorf("Unexpected summary %q", summary)
+	}
+}
+
+func TestResourcesValidate(t *testing.T) {
+
+	invalid := []Resources{
+		{Memory: "512mb"},
+		{Memory: "lots"},
+		{MemorySwap: "1g"},
+		{Cpus: "0"},
+		{Pids: -1},
+		{Ulimits: []string{"nofile"}},
+	}
+
+	for _, resources := range invalid {
+		if err := resources.Validate(); err == nil {
+			t.Errorf("Expected an error for %+v", resources)
+		}
+	}
+
+	if err := (Resources{Memory: "1g", MemorySwap: "-1"}).Validate(); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,88 @@
+package container
+
+import (
+	"github.com/SnowRipple/crane/constants"
+	"github.com/SnowRipple/crane/exit"
+	"strconv"
+	"strings"
+	"time"
+)
+
+/*
+ Restart policies applied by crane supervise
+*/
+const (
+	RESTART_NO         = "no"
+	RESTART_ON_FAILURE = "on-failure"
+	RESTART_ALWAYS     = "always"
+
+	DEFAULT_RESTART_BACKOFF = 1 * time.Second
+	MAX_RESTART_BACKOFF     = 5 * time.Minute
+)
+
+//Parsed RESTART policy of a container (e.g. "on-failure:5").
+type RestartPolicy struct {
+	Name       string
+	MaxRetries int //on-failure only, 0 means unlimited retries
+	Backoff    time.Duration
+}
+
+//Parses the RESTART and RESTARTBACKOFF values of a container.An empty policy means no restarts.
+func ParseRestartPolicy(restart, backoff string) (RestartPolicy, error) {
+
+	policy := RestartPolicy{Name: RESTART_NO, Backoff: DEFAULT_RESTART_BACKOFF}
+
+	if len(backoff) > 0 {
+		duration, err := time.ParseDuration(backoff)
+		if err != nil || duration <= 0 {
+			return policy, exit.Errorf(exit.CONFIG_ERROR, "Invalid RESTARTBACKOFF %q.Please use a positive duration (e.g. \"2s\").", backoff)
+		}
+		policy.Backoff = duration
+	}
+
+	parts := strings.SplitN(restart, constants.COMMANDS_DELIMITER, 2)
+	switch parts[0] {
+	case "", RESTART_NO:
+		return policy, nil
+	case RESTART_ALWAYS:
+		if len(parts) == 1 {
+			policy.Name = RESTART_ALWAYS
+			return policy, nil
+		}
+	case RESTART_ON_FAILURE:
+		policy.Name = RESTART_ON_FAILURE
+		if len(parts) == 1 {
+			return policy, nil
+		}
+		if maxRetries, err := strconv.Atoi(parts[1]); err == nil && maxRetries > 0 {
+			policy.MaxRetries = maxRetries
+			return policy, nil
+		}
+	}
+	return policy, exit.Errorf(exit.CONFIG_ERROR, "Invalid RESTART %q.Please use %q, %q, %q or %q.", restart, RESTART_NO, RESTART_ALWAYS, RESTART_ON_FAILURE, RESTART_ON_FAILURE+":<maxRetries>")
+}
+
+//Checks if a container that exited with a given exit code should be restarted after given number of restarts.
+func (policy RestartPolicy) ShouldRestart(exitCode, restarts int) bool {
+
+	switch policy.Name {
+	case RESTART_ALWAYS:
+		return true
+	case RESTART_ON_FAILURE:
+		return exitCode != 0 && (policy.MaxRetries == 0 || restarts < policy.MaxRetries)
+	}
+	return false
+}
+
+//Returns how long to wait before the next restart.The delay doubles with every consecutive failure up to MAX_RESTART_BACKOFF.
+func (policy RestartPolicy) Delay(consecutiveFailures int) time.Duration {
+
+	delay := policy.Backoff
+	for index := 0; index < consecutiveFailures && delay < MAX_RESTART_BACKOFF; index++ {
+		delay *= 2
+	}
+	if delay > MAX_RESTART_BACKOFF {
+		return MAX_RESTART_BACKOFF
+	}
+	return delay
+}
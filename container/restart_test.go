@@ -0,0 +1,48 @@
+package container
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseRestartPolicy(t *testing.T) {
+
+	policy, err := ParseRestartPolicy("on-failure:3", "2s")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if policy.Name != RESTART_ON_FAILURE || policy.MaxRetries != 3 || policy.Backoff != 2*time.Second {
+		t.Errorf("Unexpected policy %+v", policy)
+	}
+
+	for _, restart := range []string{"sometimes", "on-failure:0", "on-failure:x", "always:3"} {
+		if _, err := ParseRestartPolicy(restart, ""); err == nil {
+			t.Errorf("Expected an error for %q", restart)
+		}
+	}
+}
+
+func TestRestartPolicyShouldRestart(t *testing.T) {
+
+	onFailure := RestartPolicy{Name: RESTART_ON_FAILURE, MaxRetries: 2}
+	if onFailure.ShouldRestart(0, 0) {
+		t.Errorf("Successful exit must not be restarted by %q", RESTART_ON_FAILURE)
+	}
+	if !onFailure.ShouldRestart(1, 1) || onFailure.ShouldRestart(1, 2) {
+		t.Errorf("%q must restart at most %d times", RESTART_ON_FAILURE, onFailure.MaxRetries)
+	}
+	if !(RestartPolicy{Name: RESTART_ALWAYS}).ShouldRestart(0, 100) {
+		t.Errorf("%q must always restart", RESTART_ALWAYS)
+	}
+}
+
+func TestRestartPolicyDelay(t *testing.T) {
+
+	policy := RestartPolicy{Backoff: time.Second}
+	if delay := policy.Delay(3); delay != 8*time.Second {
+		t.Errorf("Delay(3) = %v; expected 8s", delay)
+	}
+	if delay := policy.Delay(100); delay != MAX_RESTART_BACKOFF {
+		t.Errorf("Delay(100) = %v; expected %v", delay, MAX_RESTART_BACKOFF)
+	}
+}
@@ -0,0 +1,98 @@
+package container
+
+import (
+	"github.com/SnowRipple/crane/constants"
+	"github.com/SnowRipple/crane/exit"
+	"os"
+	"regexp"
+	"strings"
+)
+
+/*
+ Docker options restricting what a container can do
+*/
+const (
+	CAP_ADD_OPTION      = "--cap-add="
+	CAP_DROP_OPTION     = "--cap-drop="
+	READ_ONLY_OPTION    = "--read-only"
+	USER_OPTION         = "--user="
+	SECURITY_OPT_OPTION = "--security-opt="
+
+	NO_NEW_PRIVILEGES = "no-new-privileges"
+	SECCOMP_PROFILE   = "seccomp="
+	APPARMOR_PROFILE  = "apparmor="
+	UNCONFINED        = "unconfined"
+)
+
+//Capabilities like "NET_ADMIN", "CAP_NET_ADMIN" or "ALL".
+var capabilityPattern = regexp.MustCompile(`^[A-Za-z_]+$`)
+
+//Security settings of a container defined in the [containers.<name>.security] table of the Cranefile.
+type Security struct {
+	CapAdd          []string //Capabilities added to the default set e.g. ["NET_ADMIN"]
+	CapDrop         []string //Capabilities dropped from the default set e.g. ["ALL"]
+	ReadOnly        bool     //Mount the root filesystem read-only
+	User            string   //Run the container's processes as a given user name or uid
+	Group           string   //Run the container's processes with a given group name or gid (requires USER)
+	NoNewPrivileges bool     //Processes can't gain privileges (e.g. through setuid binaries)
+	Seccomp         string   //Path to a seccomp profile or "unconfined"
+	Apparmor        string   //Name of an AppArmor profile loaded on the host or "unconfined"
+}
+
+//Checks the security settings.The seccomp profile is read by the docker client so it must exist on this machine.
+func (security Security) Validate() error {
+
+	for _, capability := range append(append([]string{}, security.CapAdd...), security.CapDrop...) {
+		if !capabilityPattern.MatchString(capability) {
+			return exit.Errorf(exit.CONFIG_ERROR, "Invalid capability %q.Please use capability names like \"NET_ADMIN\" or \"ALL\".", capability)
+		}
+	}
+	if len(security.Group) > 0 && len(security.User) == 0 {
+		return exit.Errorf(exit.CONFIG_ERROR, "GROUP can only be set together with USER.Please correct.")
+	}
+	if strings.Contains(security.User, constants.COMMANDS_DELIMITER) || strings.Contains(security.Group, constants.COMMANDS_DELIMITER) {
+		return exit.Errorf(exit.CONFIG_ERROR, "USER and GROUP must not contain %q, please set the group with GROUP.", constants.COMMANDS_DELIMITER)
+	}
+	if len(security.Seccomp) > 0 && security.Seccomp != UNCONFINED {
+		if _, err := os.Stat(security.Seccomp); err != nil {
+			return exit.Errorf(exit.CONFIG_ERROR, "Seccomp profile %q can't be read: %v", security.Seccomp, err)
+		}
+	}
+	return nil
+}
+
+//Builds docker options applying the security settings.
+func (security Security) Options() ([]string, error) {
+
+	if err := security.Validate(); err != nil {
+		return nil, err
+	}
+
+	var options []string
+	for _, capability := range security.CapAdd {
+		options = append(options, CAP_ADD_OPTION+strings.ToUpper(capability))
+	}
+	for _, capability := range security.CapDrop {
+		options = append(options, CAP_DROP_OPTION+strings.ToUpper(capability))
+	}
+	if security.ReadOnly {
+		options = append(options, READ_ONLY_OPTION)
+	}
+	if len(security.User) > 0 {
+		user := security.User
+		if len(security.Group) > 0 {
+			user += constants.COMMANDS_DELIMITER + security.Group
+		}
+		options = append(options, USER_OPTION+user)
+	}
+	if security.NoNewPrivileges {
+		options = append(options, SECURITY_OPT_OPTION+NO_NEW_PRIVILEGES)
+	}
+	if len(security.Seccomp) > 0 {
+		options = append(options, SECURITY_OPT_OPTION+SECCOMP_PROFILE+security.Seccomp)
+	}
+	if len(security.Apparmor) > 0 {
+		options = append(options, SECURITY_OPT_OPTION+APPARMOR_PROFILE+security.Apparmor)
+	}
+	return options, nil
+}
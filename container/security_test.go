@@ -0,0 +1,41 @@
+package container
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSecurityOptions(t *testing.T) {
+
+	security := Security{CapAdd: []string{"net_admin"}, CapDrop: []string{"ALL"}, ReadOnly: true, User: "1000", Group: "1000", NoNewPrivileges: true, Seccomp: UNCONFINED, Apparmor: "docker-default"}
+	expected := []string{"--cap-add=NET_ADMIN", "--cap-drop=ALL", "--read-only", "--user=1000:1000", "--security-opt=no-new-privileges", "--security-opt=seccomp=unconfined", "--security-opt=apparmor=docker-default"}
+
+	options, err := security.Options()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(options, expected) {
+		t.Errorf("Options() = %q; expected %q", options, expected)
+	}
+}
+
+func TestBuildRunCommandPrivilegedIsOptIn(t *testing.T) {
+
+	command, err := BuildRunCommand(Host{}, Container{Image: "ubuntu"}, "web", false, false)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for _, argument := range command {
+		if argument == PRIVILEDGED_OPTION {
+			t.Errorf("Container was not asked to be privileged: %q", command)
+		}
+	}
+
+	command, err = BuildRunCommand(Host{}, Container{Image: "ubuntu", Privileged: true}, "web", false, false)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if command[3] != PRIVILEDGED_OPTION {
+		t.Errorf("Privileged container is missing %q: %q", PRIVILEDGED_OPTION, command)
+	}
+}
@@ -0,0 +1,84 @@
+package container
+
+import (
+	"strconv"
+	"strings"
+)
+
+/*
+ Snapshots of frozen containers
+*/
+const (
+	SNAPSHOT_TAG_PREFIX    = "snap-"
+	SNAPSHOT_TAG_DELIMITER = "-"
+	TAG_DELIMITER          = ":"
+	PATH_DELIMITER         = "/"
+)
+
+//Image a container was frozen into by crane freeze, recorded in the state file.
+type Snapshot struct {
+	Image   string //e.g. "example/web:snap-web-3"
+	ID      string //Image id
+	Created string //RFC 3339 time of the freeze
+}
+
+//Returns the tag of the snapshot's image e.g. "snap-web-3".
+func (snapshot Snapshot) Tag() string {
+	return strings.TrimPrefix(snapshot.Image, ImageRepository(snapshot.Image)+TAG_DELIMITER)
+}
+
+//Returns the image name without its tag e.g. "example/web" for "example/web:1.0" or "localhost:5000/web" for "localhost:5000/web".
+func ImageRepository(image string) string {
+
+	separator := strings.LastIndex(image, TAG_DELIMITER)
+	if separator < 0 || strings.Contains(image[separator:], PATH_DELIMITER) { //A registry port, not a tag
+		return image
+	}
+	return image[:separator]
+}
+
+//Returns the image name of the next snapshot of a container using a given image e.g. "example/web:snap-web-4" after "snap-web-3".
+//The tag carries the container name since containers sharing a Cranefile image share the repository of their snapshots.
+func NextSnapshotImage(image, containerName string, history []Snapshot) string {
+
+	tagPrefix := SNAPSHOT_TAG_PREFIX + containerName + SNAPSHOT_TAG_DELIMITER
+
+	last := 0
+	for _, snapshot := range history {
+		if !strings.HasPrefix(snapshot.Tag(), tagPrefix) {
+			continue
+		}
+		if number, err := strconv.Atoi(strings.TrimPrefix(snapshot.Tag(), tagPrefix)); err == nil && number > last {
+			last = number
+		}
+	}
+	return ImageRepository(image) + TAG_DELIMITER + tagPrefix + strconv.Itoa(last+1)
+}
+
+//Finds a snapshot by its tag ("snap-web-2") or image name ("example/web:snap-web-2").
+func FindSnapshot(history []Snapshot, name string) (Snapshot, bool) {
+
+	for _, snapshot := range history {
+		if snapshot.Image == name || snapshot.Tag() == name {
+			return snapshot, true
+		}
+	}
+	return Snapshot{}, false
+}
+
+//Returns the snapshot taken before the current one, or the latest snapshot when the container does not run from a snapshot.
+func PreviousSnapshot(history []Snapshot, current string) (Snapshot, bool) {
+
+	for index := len(history) - 1; index >= 0; index-- {
+		if history[index].Image == current {
+			if index == 0 {
+				return Snapshot{}, false
+			}
+			return history[index-1], true
+		}
+	}
+	if len(history) == 0 {
+		return Snapshot{}, false
+	}
+	return history[len(history)-1], true
+}
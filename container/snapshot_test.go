@@ -0,0 +1,61 @@
+package container
+
+import (
+	"testing"
+)
+
+func TestSnapshotImages(t *testing.T) {
+
+	repositories := map[string]string{
+		"example/web":               "example/web",
+		"example/web:1.0":           "example/web",
+		"localhost:5000/web":        "localhost:5000/web",
+		"localhost:5000/web:snap-2": "localhost:5000/web",
+	}
+	for image, expected := range repositories {
+		if repository := ImageRepository(image); repository != expected {
+			t.Errorf("Repository of %q is %q; expected %q", image, repository, expected)
+		}
+	}
+
+	history := []Snapshot{{Image: "example/web:snap-web-1"}, {Image: "example/web:snap-web-3"}}
+	if next := NextSnapshotImage("example/web:1.0", "web", history); next != "example/web:snap-web-4" {
+		t.Errorf("Next snapshot is %q; expected %q", next, "example/web:snap-web-4")
+	}
+	if next := NextSnapshotImage("example/web", "web", nil); next != "example/web:snap-web-1" {
+		t.Errorf("First snapshot is %q; expected %q", next, "example/web:snap-web-1")
+	}
+
+	if snapshot, exists := FindSnapshot(history, "snap-web-3"); !exists || snapshot.Image != "example/web:snap-web-3" {
+		t.Errorf("Snapshot snap-web-3 not found")
+	}
+	if previous, exists := PreviousSnapshot(history, ""); !exists || previous.Tag() != "snap-web-3" {
+		t.Errorf("Previous snapshot of the Cranefile image is %q; expected %q", previous.Tag(), "snap-web-3")
+	}
+	if previous, exists := PreviousSnapshot(history, "example/web:snap-web-3"); !exists || previous.Tag() != "snap-web-1" {
+		t.Errorf("Previous snapshot of snap-web-3 is %q; expected %q", previous.Tag(), "snap-web-1")
+	}
+	if _, exists := PreviousSnapshot(history, "example/web:snap-web-1"); exists {
+		t.Errorf("Oldest snapshot has a previous snapshot")
+	}
+}
+
+func TestNextSnapshotImage_sharedImage(t *testing.T) {
+
+	image := "orobix/sshfs_startup_key"
+
+	first := NextSnapshotImage(image, "firstContainer", nil)
+	second := NextSnapshotImage(image, "secondContainer", nil)
+	if first == second {
+		t.Fatalf("Containers sharing image %q got the same snapshot %q", image, first)
+	}
+
+	//Tags of other containers or older snap-N tags do not shift the numbering
+	history := []Snapshot{{Image: first}, {Image: second}, {Image: image + ":snap-7"}}
+	if next := NextSnapshotImage(image, "firstContainer", history); next != image+":snap-firstContainer-2" {
+		t.Errorf("Next snapshot is %q; expected %q", next, image+":snap-firstContainer-2")
+	}
+	if next := NextSnapshotImage(image, "secondContainer", []Snapshot{{Image: second}}); next != image+":snap-secondContainer-2" {
+		t.Errorf("Next snapshot is %q; expected %q", next, image+":snap-secondContainer-2")
+	}
+}
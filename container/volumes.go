@@ -0,0 +1,138 @@
+package container
+
+import (
+	"github.com/SnowRipple/crane/constants"
+	"github.com/SnowRipple/crane/exit"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+/*
+ Mountpoints and tmpfs mounts
+*/
+const (
+	TMPFS_OPTION = "--tmpfs="
+	TMPFS_SIZE   = "size="
+
+	READ_ONLY_MODE  = "ro"
+	READ_WRITE_MODE = "rw"
+	HOME_PREFIX     = "~"
+
+	MIN_TMPFS_ARGUMENT_COUNT = 1
+	MAX_TMPFS_ARGUMENT_COUNT = 2
+)
+
+//Names of docker volumes e.g. "pgdata".Anything containing a slash is a host path.
+var volumeNamePattern = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.-]*$`)
+
+//Single entry of MOUNTPOINTS with its source resolved.
+type Mountpoint struct {
+	Source string //Absolute host path or the name of a volume
+	Target string
+	Mode   string
+	Named  bool //Source is a named volume managed by crane
+}
+
+//Parses a [source, target, mode] entry of MOUNTPOINTS.
+//Sources starting with "~" are relative to the home directory, other relative paths (e.g. "./data") to the Cranefile directory.
+//A plain name (e.g. "pgdata") refers to a named volume.
+func ParseMountpoint(mountpoint []string) (Mountpoint, error) {
+
+	if len(mountpoint) != MOUNTPOINTS_ARGUMENT_COUNT {
+		return Mountpoint{}, exit.Errorf(exit.CONFIG_ERROR, "Wrong amount of mountpoint arguments in %q: Expected %d, Actual %d.Please correct", mountpoint, MOUNTPOINTS_ARGUMENT_COUNT, len(mountpoint))
+	}
+
+	parsed := Mountpoint{Source: mountpoint[0], Target: mountpoint[1], Mode: mountpoint[2]}
+
+	if parsed.Mode != READ_ONLY_MODE && parsed.Mode != READ_WRITE_MODE {
+		return parsed, exit.Errorf(exit.CONFIG_ERROR, "Invalid mode %q of mountpoint %q.Please use %q or %q.", parsed.Mode, mountpoint, READ_ONLY_MODE, READ_WRITE_MODE)
+	}
+	if !filepath.IsAbs(parsed.Target) {
+		return parsed, exit.Errorf(exit.CONFIG_ERROR, "Container path %q of mountpoint %q must be absolute.Please correct.", parsed.Target, mountpoint)
+	}
+
+	if volumeNamePattern.MatchString(parsed.Source) {
+		parsed.Named = true
+		return parsed, nil
+	}
+
+	source, err := resolveHostPath(parsed.Source)
+	if err != nil {
+		return parsed, err
+	}
+	parsed.Source = source
+	return parsed, nil
+}
+
+//Expands "~" and makes relative paths absolute against the Cranefile directory.
+func resolveHostPath(path string) (string, error) {
+
+	if path == HOME_PREFIX || strings.HasPrefix(path, HOME_PREFIX+"/") {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", exit.Errorf(exit.CONFIG_ERROR, "Failed to expand %q: %v", path, err)
+		}
+		return filepath.Join(home, strings.TrimPrefix(path, HOME_PREFIX)), nil
+	}
+
+	if filepath.IsAbs(path) {
+		return filepath.Clean(path), nil
+	}
+
+	cranefileDirectory, err := filepath.Abs(filepath.Dir(constants.CONFIGURATION_FILE))
+	if err != nil {
+		return "", exit.Errorf(exit.CONFIG_ERROR, "Failed to resolve %q: %v", path, err)
+	}
+	return filepath.Join(cranefileDirectory, path), nil
+}
+
+//Checks that the source of a bind mount exists so docker does not silently create an empty directory owned by root.
+func (mountpoint Mountpoint) CheckSource() error {
+
+	if mountpoint.Named {
+		return nil
+	}
+	if _, err := os.Stat(mountpoint.Source); err != nil {
+		return exit.Errorf(exit.CONFIG_ERROR, "Host path %q mounted at %q does not exist.Please create it or correct MOUNTPOINTS.", mountpoint.Source, mountpoint.Target)
+	}
+	return nil
+}
+
+//Returns names of the named volumes used by a container.
+func NamedVolumes(mountpoints [][]string) []string {
+
+	var volumes []string
+	for _, mountpoint := range mountpoints {
+		if parsed, err := ParseMountpoint(mountpoint); err == nil && parsed.Named {
+			volumes = append(volumes, parsed.Source)
+		}
+	}
+	return volumes
+}
+
+//Builds docker tmpfs options from [path] or [path, size] entries of TMPFS.
+func buildTmpfsCommands(tmpfs [][]string) ([]string, error) {
+
+	var tmpfsCommands []string
+
+	for _, mount := range tmpfs {
+		if len(mount) < MIN_TMPFS_ARGUMENT_COUNT || len(mount) > MAX_TMPFS_ARGUMENT_COUNT {
+			return nil, exit.Errorf(exit.CONFIG_ERROR, "Wrong amount of tmpfs arguments in %q: Expected [path] or [path, size].Please correct", mount)
+		}
+		if !filepath.IsAbs(mount[0]) {
+			return nil, exit.Errorf(exit.CONFIG_ERROR, "Tmpfs path %q must be absolute.Please correct.", mount[0])
+		}
+
+		tmpfsCommand := TMPFS_OPTION + mount[0]
+		if len(mount) == MAX_TMPFS_ARGUMENT_COUNT {
+			if !sizePattern.MatchString(mount[1]) {
+				return nil, exit.Errorf(exit.CONFIG_ERROR, "Invalid tmpfs size %q.Please use a number with an optional unit b, k, m or g (e.g. \"64m\").", mount[1])
+			}
+			tmpfsCommand += constants.COMMANDS_DELIMITER + TMPFS_SIZE + strings.ToLower(mount[1])
+		}
+		tmpfsCommands = append(tmpfsCommands, tmpfsCommand)
+	}
+	return tmpfsCommands, nil
+}
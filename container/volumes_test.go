@@ -0,0 +1,72 @@
+package container
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParseMountpoint(t *testing.T) {
+
+	workingDirectory, _ := os.Getwd()
+	home, _ := os.UserHomeDir()
+
+	cases := []struct {
+		mountpoint []string
+		expected   Mountpoint
+	}{
+		{[]string{"/srv/data", "/data", "rw"}, Mountpoint{Source: "/srv/data", Target: "/data", Mode: "rw"}},
+		{[]string{"./data", "/data", "ro"}, Mountpoint{Source: filepath.Join(workingDirectory, "data"), Target: "/data", Mode: "ro"}},
+		{[]string{"~/projects", "/projects", "rw"}, Mountpoint{Source: filepath.Join(home, "projects"), Target: "/projects", Mode: "rw"}},
+		{[]string{"pgdata", "/var/lib/postgresql", "rw"}, Mountpoint{Source: "pgdata", Target: "/var/lib/postgresql", Mode: "rw", Named: true}},
+	}
+
+	for _, testCase := range cases {
+		parsed, err := ParseMountpoint(testCase.mountpoint)
+		if err != nil {
+			t.Fatalf("Unexpected error for %q: %v", testCase.mountpoint, err)
+		}
+		if parsed != testCase.expected {
+			t.Errorf("ParseMountpoint(%q) = %+v; expected %+v", testCase.mountpoint, parsed, testCase.expected)
+		}
+	}
+
+	for _, invalid := range [][]string{{"/srv", "/data"}, {"/srv", "data", "rw"}, {"/srv", "/data", "rwx"}} {
+		if _, err := ParseMountpoint(invalid); err == nil {
+			t.Errorf("Expected an error for %q", invalid)
+		}
+	}
+}
+
+func TestNamedVolumes(t *testing.T) {
+
+	mountpoints := [][]string{
+		{"pgdata", "/var/lib/postgresql", "rw"},
+		{"/srv/data", "/data", "rw"},
+		{"./logs", "/logs", "rw"},
+		{"~/projects", "/projects", "ro"},
+		{"cache", "/cache"}, //Invalid, never created nor removed
+		{"shared", "/shared", "ro"},
+	}
+	expected := []string{"pgdata", "shared"}
+
+	if volumes := NamedVolumes(mountpoints); !reflect.DeepEqual(volumes, expected) {
+		t.Errorf("NamedVolumes = %q; expected %q", volumes, expected)
+	}
+}
+
+func TestBuildTmpfsCommands(t *testing.T) {
+
+	commands, err := buildTmpfsCommands([][]string{{"/tmp", "64M"}, {"/run"}})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if expected := []string{"--tmpfs=/tmp:size=64m", "--tmpfs=/run"}; !reflect.DeepEqual(commands, expected) {
+		t.Errorf("buildTmpfsCommands() = %q; expected %q", commands, expected)
+	}
+
+	if _, err := buildTmpfsCommands([][]string{{"/tmp", "lots"}}); err == nil {
+		t.Errorf("Expected an error for an invalid size")
+	}
+}
@@ -2,11 +2,17 @@ package main
 
 import (
 	"fmt"
+	"github.com/SnowRipple/crane/cancellation"
+	"github.com/SnowRipple/crane/command"
+	"github.com/SnowRipple/crane/command/executer"
 	"github.com/SnowRipple/crane/constants"
+	"github.com/SnowRipple/crane/dryrun"
+	"github.com/SnowRipple/crane/exit"
 	ownLog "github.com/SnowRipple/crane/logger"
+	"github.com/SnowRipple/crane/output"
+	"github.com/SnowRipple/crane/ssh"
 	flags "github.com/jessevdk/go-flags"
 	"github.com/mitchellh/cli"
-	log "github.com/op/go-logging"
 	"os"
 	"path/filepath"
 	"strings"
@@ -27,42 +33,137 @@ func main() {
 	var options constants.CommonFlags
 
 	craneArguments := os.Args[1:]
-	_, err := flags.ParseArgs(&options, craneArguments)
+	commandArguments, err := flags.ParseArgs(&options, craneArguments)
 
+	logLevel := options.LogLevel
 	if options.DebugMode {
-		log.SetLevel(log.DEBUG, LOGGER_NAME)
+		logLevel = "debug"
 	}
 
-	logger.Debug("Command line arguments provided: %v", craneArguments)
+	err = ownLog.Configure(ownLog.Settings{Level: logLevel, Format: options.LogFormat, File: options.LogFile, Syslog: options.Syslog})
+	if err != nil {
+		logger.Error("Failed to set up logging due to error: %v", err)
+		os.Exit(exit.USAGE_ERROR)
+	}
+
+	if err = output.SetFormat(options.Output); err != nil {
+		logger.Error("Failed to set up the output due to error: %v", err)
+		os.Exit(exit.USAGE_ERROR)
+	}
+
+	if err = executer.SetRuntime(options.Runtime); err != nil {
+		logger.Error("Failed to set up the container runtime due to error: %v", err)
+		os.Exit(exit.USAGE_ERROR)
+	}
+
+	if err = executer.SetEscalation(options.Escalation, options.EscalationCommand); err != nil {
+		logger.Error("Failed to set up the privilege escalation due to error: %v", err)
+		os.Exit(exit.USAGE_ERROR)
+	}
+
+	executer.EnableOffline(options.Offline)
+
+	if options.DryRun {
+		dryrun.Enable()
+	}
 
 	if options.Version {
-		craneArguments = []string{"version"}
+		commandArguments = []string{"version"}
+	} else {
+		commandArguments = withoutLeadingOptions(craneArguments)
+	}
+
+	//Secrets given on the command line must be known before the arguments are logged
+	command.RegisterArgumentSecrets(commandArguments)
+	logger.Debug("Command line arguments provided: %v", craneArguments)
+
+	if len(commandArguments) > 0 {
+		ownLog.SetField(ownLog.COMMAND_FIELD, commandArguments[0])
+		output.Begin(commandArguments[0])
 	}
 
 	cli := &cli.CLI{
-		Args:     craneArguments,
+		Args:     commandArguments,
 		Commands: Commands,
 	}
 
-	clean()
+	//Ctrl-C, SIGTERM and the --timeout stop running commands instead of killing crane outright
+	cancellation.Start(options.Timeout)
+
+	exitStatus, err := run(cli)
 
-	_, err = cli.Run()
+	if reason := cancellation.Err(); reason != nil {
+		exitStatus, err = exit.Code(reason), reason
+		cleanUpAfterCancellation()
+	}
+	cancellation.Stop()
+
+	errorMessage := ""
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error executing CLI: %s\n", err.Error())
+		exitStatus = exit.Code(err)
+		errorMessage = ownLog.Redact(err.Error())
+		fmt.Fprintf(os.Stderr, "Error executing CLI: %s\n", errorMessage)
+	}
+
+	//Machine readable result (if requested) goes to the stdout
+	output.Finish(exitStatus, errorMessage)
+
+	//Connections to containers are shared by all commands so they are closed only at the very end
+	ssh.CloseConnections()
+
+	os.Exit(exitStatus)
+}
+
+//Drops global options given before the command name (e.g. "--output json" in "crane --output json start"),
+//otherwise the first value of an option would be taken for the command.Options after the command name are left for the command.
+func withoutLeadingOptions(craneArguments []string) []string {
+
+	var leadingOptions constants.CommonFlags
+
+	parser := flags.NewParser(&leadingOptions, flags.PassAfterNonOption|flags.IgnoreUnknown)
+	commandArguments, err := parser.ParseArgs(craneArguments)
+	if err != nil {
+		return craneArguments
+	}
+	return commandArguments
+}
+
+//Runs the chosen command.Errors returned here (e.g. invalid Cranefile) did not let the command start at all.
+func run(commandLine *cli.CLI) (int, error) {
+
+	if err := clean(); err != nil {
+		return exit.FILE_ERROR, err
+	}
+	return commandLine.Run()
+}
+
+//Removes containers created by the cancelled command and cidfiles it left behind.
+func cleanUpAfterCancellation() {
+
+	if err := command.RemoveCreatedContainers(); err != nil {
+		logger.Error("Failed to clean up after the cancellation: %v", err)
+	}
+	if err := clean(); err != nil {
+		logger.Error("%v", err)
 	}
 }
 
 //Remove cidfile that could remain after previous runs
-func clean() {
+func clean() error {
 
 	err := filepath.Walk(".", traverse) //current dir
 	if err != nil {
-		logger.Fatalf("Failed to clean the directory from the last run leftovers due to error: %s", err.Error())
+		return exit.Errorf(exit.FILE_ERROR, "Failed to clean the directory from the last run leftovers due to error: %s", err.Error())
 	}
+	return nil
 }
 
 func traverse(path string, f os.FileInfo, err error) error {
 	if strings.Contains(path, constants.ID_FILE) {
+		if dryrun.Enabled() {
+			dryrun.PrintCommand([]string{"rm", path})
+			return nil
+		}
 		err := os.Remove(path)
 		if err != nil {
 			return err
@@ -0,0 +1,64 @@
+package dryrun
+
+import (
+	"fmt"
+	log "github.com/SnowRipple/crane/logger"
+	"github.com/SnowRipple/crane/output"
+	"strings"
+)
+
+//Prefix of every planned operation printed in the dry run mode.
+const PREFIX = "[dry-run] "
+
+//Characters that make an argument need quoting when printed as a shell command.
+const SHELL_SPECIAL_CHARACTERS = " \t\n\"'\\$`!*?[]{}()<>|&;#~"
+
+//In the dry run mode every operation touching docker, containers or local files is printed instead of being executed.
+var enabled bool
+
+//Turns the dry run mode on.
+func Enable() {
+	enabled = true
+}
+
+//Returns true if operations should be printed instead of executed.
+func Enabled() bool {
+	return enabled
+}
+
+//Prints an operation that would be executed.Secrets are redacted.
+func Print(format string, arguments ...interface{}) {
+	fmt.Fprintln(output.Stdout(), PREFIX+log.Redact(fmt.Sprintf(format, arguments...)))
+}
+
+//Prints a command that would be executed, quoted so it can be pasted into a shell.
+func PrintCommand(argv []string) {
+	Print("%s", Quote(argv))
+}
+
+//Prints the content a file would be written with.
+func PrintFile(filename string, lines []string) {
+
+	Print("write %s:", filename)
+	for _, line := range lines {
+		Print("    %s", line)
+	}
+}
+
+//Returns a placeholder for a value known only after an operation is executed (e.g. an id of a new container).
+func Placeholder(description string) string {
+	return "<" + description + ">"
+}
+
+//Joins arguments into a single shell command line quoting arguments where needed.
+func Quote(argv []string) string {
+
+	quoted := make([]string, len(argv))
+	for index, argument := range argv {
+		if len(argument) == 0 || strings.ContainsAny(argument, SHELL_SPECIAL_CHARACTERS) {
+			argument = "'" + strings.Replace(argument, "'", "'\\''", -1) + "'"
+		}
+		quoted[index] = argument
+	}
+	return strings.Join(quoted, " ")
+}
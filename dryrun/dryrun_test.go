@@ -0,0 +1,24 @@
+package dryrun
+
+import (
+	"testing"
+)
+
+func TestQuote(t *testing.T) {
+
+	cases := []struct {
+		argv     []string
+		expected string
+	}{
+		{[]string{"sudo", "docker", "run", "-i"}, "sudo docker run -i"},
+		{[]string{"/bin/bash", "-c", "echo hello;ls"}, "/bin/bash -c 'echo hello;ls'"},
+		{[]string{"echo", "it's"}, "echo 'it'\\''s'"},
+		{[]string{"echo", ""}, "echo ''"},
+	}
+
+	for _, testCase := range cases {
+		if quoted := Quote(testCase.argv); quoted != testCase.expected {
+			t.Errorf("Quote(%q) = %q; expected %q", testCase.argv, quoted, testCase.expected)
+		}
+	}
+}
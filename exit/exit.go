@@ -0,0 +1,50 @@
+package exit
+
+import (
+	"fmt"
+)
+
+/*
+ Exit codes of crane.
+ Commands executed inside containers (run, runall, enter) exit crane with their own exit status instead.
+*/
+const (
+	SUCCESS          = 0
+	FAILURE          = 1 //Unexpected failure
+	USAGE_ERROR      = 2 //Wrong arguments or flags
+	CONFIG_ERROR     = 3 //Invalid Cranefile or state file, unknown containers, unresolvable secrets
+	DOCKER_ERROR     = 4 //Docker command failed
+	CONNECTION_ERROR = 5 //Failed to reach a container over ssh/sftp
+	FILE_ERROR       = 6 //Failed to read or write a local file
+
+	TIMEOUT     = 124 //The --timeout or --command-timeout expired (same code as the timeout utility)
+	INTERRUPTED = 130 //Interrupted with Ctrl-C (SIGINT) or SIGTERM (same code as shells use)
+)
+
+//Error carrying the exit code crane should finish with.
+type Error struct {
+	Code    int
+	Message string
+}
+
+func (err *Error) Error() string {
+	return err.Message
+}
+
+//Creates a new error that ends crane with a given exit code.
+func Errorf(code int, format string, arguments ...interface{}) error {
+	return &Error{Code: code, Message: fmt.Sprintf(format, arguments...)}
+}
+
+//Returns the exit code matching an error.Errors without an exit code are unexpected failures.
+func Code(err error) int {
+
+	if err == nil {
+		return SUCCESS
+	}
+
+	if exitError, ok := err.(*Error); ok {
+		return exitError.Code
+	}
+	return FAILURE
+}
@@ -0,0 +1,25 @@
+package exit
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCode(t *testing.T) {
+
+	cases := []struct {
+		err      error
+		expected int
+	}{
+		{nil, SUCCESS},
+		{errors.New("unexpected"), FAILURE},
+		{Errorf(DOCKER_ERROR, "docker failed"), DOCKER_ERROR},
+		{Errorf(42, "command exited with status %d", 42), 42},
+	}
+
+	for _, testCase := range cases {
+		if code := Code(testCase.err); code != testCase.expected {
+			t.Errorf("Code(%v) = %d; expected %d", testCase.err, code, testCase.expected)
+		}
+	}
+}
@@ -2,60 +2,72 @@ package io
 
 import (
 	"bufio"
-	"bytes"
 	"github.com/SnowRipple/crane/constants"
 	"github.com/SnowRipple/crane/container"
+	"github.com/SnowRipple/crane/dryrun"
+	"github.com/SnowRipple/crane/exit"
 	log "github.com/SnowRipple/crane/logger"
 	"io"
 	"os"
+	"sort"
 	"strings"
 )
 
 const (
-	DEFAULT_CONTAINER_IP   = "Squik squik..."
-	DEFAULT_CONTAINER_ID   = "Muuu...muuuu"
-	DEFAULT_CONTAINER_NAME = "How...how"
-
-	CONTAINERS_HEADER       = "[containers]"
-	STATE_CONTAINERS_HEADER = "[statecontainers]"
-
-	ID_LINE = "ID ="
-	IP_LINE = "IP ="
+	CONTAINERS_HEADER             = "[containers]"
+	STATE_CONTAINERS_HEADER       = "[statecontainers]"
+	STATE_CONTAINER_HEADER_PREFIX = "[statecontainers."
+	SNAPSHOT_HEADER_PREFIX        = "[[snapshots."
+
+	ID_LINE       = "ID ="
+	IP_LINE       = "IP ="
+	HOST_LINE     = "HOST ="
+	PORTS_LINE    = "PORTS ="
+	SNAPSHOT_LINE = "SNAPSHOT ="
+	IMAGE_LINE    = "IMAGE ="
+	CREATED_LINE  = "CREATED ="
+
+	TEMPORARY_FILE_SUFFIX = ".tmp"
 )
 
 var logger = log.GetLogger()
 
 //Creates new config and state files.
-func Create() {
-
-	CreateNewConfigFile()
-	CreateNewStateFile()
+func Create() error {
 
+	if err := CreateNewConfigFile(); err != nil {
+		return err
+	}
+	return CreateNewStateFile()
 }
 
 //Create a new config file. If config file already exists it will be overwritten.
-func CreateNewConfigFile() {
+func CreateNewConfigFile() error {
 
 	var cranefileTemplate = []string{
 		CONTAINERS_HEADER,
 		"[containers.firstContainer]",
 		"IMAGE = \"orobix/sshfs_startup_key2\"",
 		"DOCKERFILE = \".\"",
-		"GRAPHICAL = true",
+		"GRAPHICAL = false #Open windows of GUI tools on your screen (X11)",
 		"DAEMONIZED = false",
+		"PRIVILEGED = false #Full access to the host, enable only if really needed",
 		"CWD = \"/home/foo\" #Leave empty if not needed",
 		"DNS = \"\" #Leave empty if not needed",
-		"PASSWORD = \"orobix2013\"#Leave empty if not needed",
+		"PASSWORD = \"secret:root_password\"#Use \"secret:<name>\" (see crane secret), \"env:<VARIABLE>\" or \"file:<path>\" instead of plain text.Leave empty if not needed",
 		"USERNAME = \"root\"",
 		"PORTS = [[49153, 22]]#Remember that port 22 is required for daemonized containers for SSH communication",
 		"MOUNTPOINTS=[]#Insert own mountpoints here",
 		"COMMANDS=[[\"init\",\"echo orobix\"]]"}
 
-	writeLines(cranefileTemplate, constants.CONFIGURATION_FILE)
+	if err := writeLines(cranefileTemplate, constants.CONFIGURATION_FILE); err != nil || dryrun.Enabled() {
+		return err
+	}
 
 	if _, err := CheckIfFileExists(constants.CONFIGURATION_FILE); err != nil {
-		logger.Fatalf("Failed to create file %q due to error:%v", constants.CONFIGURATION_FILE, err)
+		return exit.Errorf(exit.FILE_ERROR, "Failed to create file %q due to error:%v", constants.CONFIGURATION_FILE, err)
 	}
+	return nil
 }
 
 //Checks if a file exists.
@@ -72,17 +84,21 @@ func CheckIfFileExists(filename string) (exists bool, err error) {
 }
 
 //Retrieve container id saved in a file and then removed the filesince it is not needed anymore
-func GetContainerIdFromFile(containerName string) string {
+func GetContainerIdFromFile(containerName string) (string, error) {
 
 	filename := constants.ID_FILE + containerName
 
+	if dryrun.Enabled() { //Docker did not run so there is no cidfile
+		return dryrun.Placeholder("id of " + containerName), nil
+	}
+
 	if _, err := CheckIfFileExists(filename); err != nil {
-		logger.Fatalf("Failed to retrieve container id from  file %q due to error:%v", filename, err)
+		return "", exit.Errorf(exit.FILE_ERROR, "Failed to retrieve container id from  file %q due to error:%v", filename, err)
 	}
 
 	file, err := os.Open(filename)
 	if err != nil {
-		logger.Fatalf("Failed to open file %q due to error: %v", filename, err)
+		return "", exit.Errorf(exit.FILE_ERROR, "Failed to open file %q due to error: %v", filename, err)
 	}
 
 	reader := bufio.NewReader(file)
@@ -90,7 +106,8 @@ func GetContainerIdFromFile(containerName string) string {
 	idBytes, _, err := reader.ReadLine()
 
 	if err != nil {
-		logger.Fatalf("Error trying to read file %q : %v", filename, err)
+		file.Close()
+		return "", exit.Errorf(exit.FILE_ERROR, "Error trying to read file %q : %v", filename, err)
 	}
 
 	id := strings.TrimSpace(string(idBytes))
@@ -101,179 +118,245 @@ func GetContainerIdFromFile(containerName string) string {
 
 	defer os.Remove(filename)
 
-	return id
+	return id, nil
 }
 
 //Creates a new state file.If state file already exists it will be overwritten
-func CreateNewStateFile() {
+func CreateNewStateFile() error {
 
 	var statefileTemplate = []string{STATE_CONTAINERS_HEADER}
 
-	writeLines(statefileTemplate, constants.STATE_FILE)
+	if err := writeLines(statefileTemplate, constants.STATE_FILE); err != nil || dryrun.Enabled() {
+		return err
+	}
 
 	if _, err := CheckIfFileExists(constants.STATE_FILE); err != nil {
-		logger.Fatalf("Failed to create file %q due to error:%v", constants.STATE_FILE, err)
+		return exit.Errorf(exit.FILE_ERROR, "Failed to create file %q due to error:%v", constants.STATE_FILE, err)
 	}
+	return nil
 }
 
-//Builds a single state container text block.
-func buildStateContainer(containerName, id, ip string) []string {
+//Builds a single state container text block.The host line is written only for containers on remote hosts
+//the ports line only for containers publishing ports and the snapshot line only for containers rolled back to a snapshot.
+func buildStateContainer(containerName string, stateContainer container.StateContainer) []string {
+
+	containerLine := STATE_CONTAINER_HEADER_PREFIX + containerName + "]"
+	idLine := ID_LINE + " \"" + stateContainer.ID + "\""
+	ipLine := IP_LINE + " \"" + stateContainer.IP + "\""
+
+	lines := []string{containerLine, idLine, ipLine}
+	if len(stateContainer.Host) > 0 {
+		lines = append(lines, HOST_LINE+" \""+stateContainer.Host+"\"")
+	}
+	if len(stateContainer.Ports) > 0 {
+		lines = append(lines, PORTS_LINE+" [\""+strings.Join(stateContainer.Ports, "\", \"")+"\"]")
+	}
+	if len(stateContainer.Snapshot) > 0 {
+		lines = append(lines, SNAPSHOT_LINE+" \""+stateContainer.Snapshot+"\"")
+	}
+	return lines
+}
 
-	containerLine := "[statecontainers." + containerName + "]"
-	idLine := ID_LINE + " \"" + id + "\""
-	ipLine := IP_LINE + " \"" + ip + "\""
+//Builds the snapshot history text blocks of a container, oldest first.
+func buildSnapshots(containerName string, snapshots []container.Snapshot) []string {
 
-	return []string{containerLine, idLine, ipLine}
+	var lines []string
+	for _, snapshot := range snapshots {
+		lines = append(lines,
+			SNAPSHOT_HEADER_PREFIX+containerName+"]]",
+			IMAGE_LINE+" \""+snapshot.Image+"\"",
+			ID_LINE+" \""+snapshot.ID+"\"",
+			CREATED_LINE+" \""+snapshot.Created+"\"")
+	}
+	return lines
 }
 
-//Remove chosen containers from the state file
-func RemoveStateContainers(containersToBeRemoved []string) {
+//Builds state container text blocks in alphabetical order so the state file (and its dry run plan) is stable.
+func buildStateContainers(stateContainers map[string]container.StateContainer) []string {
+
+	var (
+		containerNames []string
+		lines          []string
+	)
+	for containerName, _ := range stateContainers {
+		containerNames = append(containerNames, containerName)
+	}
+	sort.Strings(containerNames)
+
+	for _, containerName := range containerNames {
+		lines = append(lines, buildStateContainer(containerName, stateContainers[containerName])...)
+	}
+	return lines
+}
+
+//Returns the headers of given containers' records.
+func stateContainerHeaders(containerNames []string) []string {
+
+	var headers []string
+	for _, containerName := range containerNames {
+		headers = append(headers, STATE_CONTAINER_HEADER_PREFIX+containerName+"]")
+	}
+	return headers
+}
+
+//Reads the state file leaving out records starting with given headers.
+func readStateLinesWithout(headers []string) ([]string, error) {
 
 	file, err := os.Open(constants.STATE_FILE)
 	if err != nil {
-		logger.Fatalf("Failed to open the file %q due to error: %v", constants.STATE_FILE, err)
+		return nil, exit.Errorf(exit.FILE_ERROR, "Failed to open the file %q due to error: %v", constants.STATE_FILE, err)
 	}
 
 	defer file.Close()
 
 	reader := bufio.NewReader(file)
-	buffer := bytes.NewBuffer(make([]byte, 0))
-
-	//Remove chosen containers from the state file
 
 	var (
-		//Line counter for keeping track of lines to be removed
-		counter = 0
-		lines   []string
+		skipping bool //Inside a record being left out
+		lines    []string
 	)
 
-OUTER:
 	for {
 		line, err := reader.ReadString('\n')
 		if err == io.EOF { //End of file, exit loop
 			break
 		} else if err != nil {
-			logger.Fatalf("Error trying to read file %q : %v", constants.STATE_FILE, err)
-		} else if counter > 0 {
-			counter--
-			continue //skip current line
+			return nil, exit.Errorf(exit.FILE_ERROR, "Error trying to read file %q : %v", constants.STATE_FILE, err)
 		}
 
-		if strings.Contains(line, "[statecontainers.") {
-			for _, containerName := range containersToBeRemoved {
-				if strings.Contains(line, containerName) {
-					counter = 2
-					continue OUTER //skip current line
+		if strings.HasPrefix(strings.TrimSpace(line), "[") { //A record ends where the next table starts
+			skipping = false
+			for _, header := range headers {
+				if strings.TrimSpace(line) == header {
+					logger.Debug("Leaving out the record %s from the state file", header)
+					skipping = true
+					break
 				}
 			}
 		}
-		buffer.WriteString(line)
 
-		lines = append(lines, buffer.String())
-		buffer.Reset()
+		if !skipping {
+			lines = append(lines, line)
+		}
 	}
-	writeLines(lines, constants.STATE_FILE)
+	return lines, nil
 }
 
-//Updates state file. If container already exists in the state file it is updated. If it does not exist it is added to it.
-//If state file does not exists it is created.
-func UpdateStateFile(stateContainers map[string]container.StateContainer) {
+//Remove chosen containers from the state file
+func RemoveStateContainers(containersToBeRemoved []string) error {
 
-	var (
-		currentContainerIP   = DEFAULT_CONTAINER_IP
-		currentContainerID   = DEFAULT_CONTAINER_ID
-		currentContainerName = DEFAULT_CONTAINER_NAME
-		lines                []string
-		updatedContainers    = map[string]container.StateContainer{}
-	)
+	lines, err := readStateLinesWithout(stateContainerHeaders(containersToBeRemoved))
+	if err != nil {
+		return err
+	}
+	return writeLines(lines, constants.STATE_FILE)
+}
+
+//Updates state file. If container already exists in the state file its record is replaced. If it does not exist it is added to it.
+//If state file does not exists it is created.
+func UpdateStateFile(stateContainers map[string]container.StateContainer) error {
 
 	//Create new state file if it does not exists already.
 	if exists, _ := CheckIfFileExists(constants.STATE_FILE); !exists {
-		CreateNewStateFile()
+		if dryrun.Enabled() { //Nothing to update, a new state file would list given containers only
+			return writeLines(append([]string{STATE_CONTAINERS_HEADER}, buildStateContainers(stateContainers)...), constants.STATE_FILE)
+		}
+		if err := CreateNewStateFile(); err != nil {
+			return err
+		}
 	}
 
-	file, err := os.Open(constants.STATE_FILE)
+	var containerNames []string
+	for containerName, _ := range stateContainers {
+		containerNames = append(containerNames, containerName)
+	}
+
+	lines, err := readStateLinesWithout(stateContainerHeaders(containerNames))
 	if err != nil {
-		logger.Fatalf("Failed to open the file %q due to error: %v", constants.STATE_FILE, err)
+		return err
 	}
 
-	defer file.Close()
+	lines = append(lines, buildStateContainers(stateContainers)...)
 
-	reader := bufio.NewReader(file)
-	buffer := bytes.NewBuffer(make([]byte, 0))
+	return writeLines(lines, constants.STATE_FILE)
+}
 
-	//1.Update records if they exists.
+//Replaces the snapshot history of a container in the state file.The history outlives the container's record.
+func UpdateSnapshots(containerName string, snapshots []container.Snapshot) error {
 
-	for {
-		line, err := reader.ReadString('\n')
-		if err == io.EOF { //End of file, exit loop
-			break
-		}
-		if err != nil {
-			logger.Fatalf("Error trying to read file %q : %v", constants.STATE_FILE, err)
+	if exists, _ := CheckIfFileExists(constants.STATE_FILE); !exists {
+		if err := CreateNewStateFile(); err != nil || dryrun.Enabled() {
+			return err
 		}
+	}
 
-		if strings.Contains(line, "[statecontainers.") {
-			for containerName, stateContainer := range stateContainers {
-				if strings.Contains(line, containerName) {
-					currentContainerIP = stateContainer.IP
-					currentContainerID = stateContainer.ID
-					currentContainerName = containerName
-					logger.Debug("Current container is %q with ID=%q and IP=%q", containerName, currentContainerID, currentContainerIP)
-					updatedContainers[containerName] = stateContainer
-					break
-				}
-			}
-		} else if currentContainerID != DEFAULT_CONTAINER_ID && strings.Contains(line, ID_LINE) { //Update ID
-			logger.Debug("For container %q: Current ID line is:\n%s", currentContainerName, line)
-			line = ID_LINE + " \"" + currentContainerID + "\""
-			logger.Debug("For container %q:New ID line is:\n %s", currentContainerName, line)
-
-			//Reset current container
-			currentContainerID = DEFAULT_CONTAINER_ID
-		} else if currentContainerIP != DEFAULT_CONTAINER_IP && strings.Contains(line, IP_LINE) { //Update IP
-			logger.Debug("For container %q: Current IP line is:\n%s", currentContainerName, line)
-			line = IP_LINE + " \"" + currentContainerIP + "\""
-			logger.Debug("For container %q: New IP line is:\n %s", currentContainerName, line)
-
-			//Reset current container
-			currentContainerIP = DEFAULT_CONTAINER_IP
-		}
+	lines, err := readStateLinesWithout([]string{SNAPSHOT_HEADER_PREFIX + containerName + "]]"})
+	if err != nil {
+		return err
+	}
+
+	lines = append(lines, buildSnapshots(containerName, snapshots)...)
 
-		buffer.WriteString(line)
+	return writeLines(lines, constants.STATE_FILE)
+}
+
+//Appends a single line to a file, creating the file if needed.In the dry run mode the line is printed instead.
+func AppendLine(line, filename string) error {
 
-		lines = append(lines, buffer.String())
-		buffer.Reset()
+	if dryrun.Enabled() {
+		dryrun.Print("append to %s: %s", filename, line)
+		return nil
 	}
 
-	//2.Add new containers to the state file if they don't exist yet.
+	file, err := os.OpenFile(filename, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return exit.Errorf(exit.FILE_ERROR, "Failed to open the file %q due to error: %v", filename, err)
+	}
+	defer file.Close()
 
-	for containerName, stateContainer := range stateContainers {
-		if _, ok := updatedContainers[containerName]; !ok {
-			logger.Debug("New container %q will be added to the state file", containerName)
-			//If key does not exists in the updatedContainers it means that it wasn't updated hence it must be created and added.
+	if _, err := file.WriteString(strings.TrimSpace(line) + "\n"); err != nil {
+		return exit.Errorf(exit.FILE_ERROR, "Failed to write to a file %q due to error:%v", filename, err)
+	}
+	return nil
+}
 
-			newContainerLines := buildStateContainer(containerName, stateContainer.ID, stateContainer.IP)
+//Writes lines into a temporary file first and then renames it so the file is never left half written (e.g. when crane is interrupted).
+//In the dry run mode the content is printed instead.
+func writeLines(lines []string, filename string) error {
 
-			lines = append(lines, newContainerLines...)
+	if dryrun.Enabled() {
+		var trimmedLines []string
+		for _, item := range lines {
+			trimmedLines = append(trimmedLines, strings.TrimSpace(item))
 		}
+		dryrun.PrintFile(filename, trimmedLines)
+		return nil
 	}
 
-	writeLines(lines, constants.STATE_FILE)
-}
+	temporaryFilename := filename + TEMPORARY_FILE_SUFFIX
 
-func writeLines(lines []string, filename string) {
-
-	file, err := os.Create(filename)
+	file, err := os.Create(temporaryFilename)
 	if err != nil {
-		logger.Fatalf("Failed to create file %q due to error:%v", filename, err)
+		return exit.Errorf(exit.FILE_ERROR, "Failed to create file %q due to error:%v", temporaryFilename, err)
 	}
-	defer file.Close()
 
 	for _, item := range lines {
 		_, err := file.WriteString(strings.TrimSpace(item) + "\n")
 		if err != nil {
-			logger.Fatalf("Failed to write to a file %q due to error:", filename, err)
+			file.Close()
+			os.Remove(temporaryFilename)
+			return exit.Errorf(exit.FILE_ERROR, "Failed to write to a file %q due to error:%v", temporaryFilename, err)
 		}
 	}
+
+	if err := file.Close(); err != nil {
+		os.Remove(temporaryFilename)
+		return exit.Errorf(exit.FILE_ERROR, "Failed to write to a file %q due to error:%v", temporaryFilename, err)
+	}
+
+	if err := os.Rename(temporaryFilename, filename); err != nil {
+		os.Remove(temporaryFilename)
+		return exit.Errorf(exit.FILE_ERROR, "Failed to replace file %q due to error:%v", filename, err)
+	}
+	return nil
 }
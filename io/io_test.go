@@ -0,0 +1,65 @@
+package io
+
+import (
+	"github.com/SnowRipple/crane/constants"
+	"github.com/SnowRipple/crane/container"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestUpdateAndRemoveStateContainers(t *testing.T) {
+
+	directory, err := ioutil.TempDir("", "crane")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(directory)
+
+	workingDirectory, _ := os.Getwd()
+	defer os.Chdir(workingDirectory)
+	if err := os.Chdir(directory); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := UpdateStateFile(map[string]container.StateContainer{
+		"web":    {ID: "1", IP: "172.17.0.2"},
+		"webapp": {ID: "2", IP: "172.17.0.3", Host: "build"},
+	}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := UpdateStateFile(map[string]container.StateContainer{"web": {ID: "3", IP: "172.17.0.4"}}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if err := RemoveStateContainers([]string{"webapp"}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	content, err := ioutil.ReadFile(constants.STATE_FILE)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "[statecontainers]\n[statecontainers.web]\nID = \"3\"\nIP = \"172.17.0.4\"\n"
+	if string(content) != expected {
+		t.Errorf("State file is:\n%s\nexpected:\n%s", content, expected)
+	}
+}
+
+func TestBuildStateContainerWithHost(t *testing.T) {
+
+	lines := buildStateContainer("db", container.StateContainer{ID: "1", IP: "10.0.0.2", Host: "build"})
+
+	if len(lines) != 4 || lines[3] != "HOST = \"build\"" {
+		t.Errorf("Unexpected state container record: %q", lines)
+	}
+}
+
+func TestBuildStateContainerWithPorts(t *testing.T) {
+
+	lines := buildStateContainer("web", container.StateContainer{ID: "1", IP: "172.17.0.2", Ports: []string{"22/tcp -> 0.0.0.0:49153", "53/udp -> 127.0.0.1:5353"}})
+
+	if len(lines) != 4 || lines[3] != "PORTS = [\"22/tcp -> 0.0.0.0:49153\", \"53/udp -> 127.0.0.1:5353\"]" {
+		t.Errorf("Unexpected state container record: %q", lines)
+	}
+}
@@ -0,0 +1,101 @@
+package logger
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+/*
+ Structured fields attached to log records
+*/
+const (
+	COMMAND_FIELD     = "command"
+	CONTAINER_FIELD   = "container"
+	DOCKER_ARGV_FIELD = "docker_argv"
+)
+
+//Fields describing what crane is doing at the moment, attached to every log record.
+var (
+	fields      = map[string]interface{}{}
+	fieldsMutex sync.RWMutex
+)
+
+//Sets a field that is attached to all subsequent log records.Fields describing a single container or docker command
+//should be scoped with ScopeField instead so they do not stick to later records.
+func SetField(key string, value interface{}) {
+
+	fieldsMutex.Lock()
+	defer fieldsMutex.Unlock()
+
+	fields[key] = value
+}
+
+//Sets a field until the returned function is called, it restores the previous value then:
+//
+//	defer logger.ScopeField(logger.CONTAINER_FIELD, containerName)()
+func ScopeField(key string, value interface{}) (restore func()) {
+
+	fieldsMutex.Lock()
+	defer fieldsMutex.Unlock()
+
+	previous, existed := fields[key]
+	fields[key] = value
+
+	return func() {
+		fieldsMutex.Lock()
+		defer fieldsMutex.Unlock()
+
+		if existed {
+			fields[key] = previous
+		} else {
+			delete(fields, key)
+		}
+	}
+}
+
+//Stops attaching a field to log records.
+func RemoveField(key string) {
+
+	fieldsMutex.Lock()
+	defer fieldsMutex.Unlock()
+
+	delete(fields, key)
+}
+
+//Returns a copy of the current fields.
+func Fields() map[string]interface{} {
+
+	fieldsMutex.RLock()
+	defer fieldsMutex.RUnlock()
+
+	copied := make(map[string]interface{}, len(fields))
+	for key, value := range fields {
+		copied[key] = value
+	}
+	return copied
+}
+
+//Returns fields as " key=value" pairs sorted by key for the text format.The command is left out as it is the same for the whole run.
+func textFields() string {
+
+	current := Fields()
+	delete(current, COMMAND_FIELD)
+
+	var keys []string
+	for key := range current {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var text strings.Builder
+	for _, key := range keys {
+		value := current[key]
+		if argv, isArgv := value.([]string); isArgv {
+			value = strings.Join(argv, " ")
+		}
+		fmt.Fprintf(&text, " %s=%q", key, fmt.Sprint(value))
+	}
+	return text.String()
+}
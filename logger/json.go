@@ -0,0 +1,60 @@
+package logger
+
+import (
+	"encoding/json"
+	log "github.com/op/go-logging"
+	"io"
+	"sync"
+	"time"
+)
+
+//Backend writing every record as a single JSON object per line, together with the structured fields.
+type jsonBackend struct {
+	writer io.Writer
+	mutex  sync.Mutex
+}
+
+func newJsonBackend(writer io.Writer) log.Backend {
+	return &jsonBackend{writer: writer}
+}
+
+func (backend *jsonBackend) Log(level log.Level, calldepth int, record *log.Record) error {
+
+	entry := map[string]interface{}{}
+
+	for key, value := range Fields() {
+		entry[key] = redactValue(value)
+	}
+
+	entry["time"] = record.Time.Format(time.RFC3339Nano)
+	entry["level"] = level.String()
+	entry["id"] = record.ID
+	entry["message"] = Redact(record.Message())
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	backend.mutex.Lock()
+	defer backend.mutex.Unlock()
+
+	_, err = backend.writer.Write(append(line, '\n'))
+	return err
+}
+
+//Redacts secrets from field values.
+func redactValue(value interface{}) interface{} {
+
+	switch typedValue := value.(type) {
+	case string:
+		return Redact(typedValue)
+	case []string:
+		redacted := make([]string, len(typedValue))
+		for index, part := range typedValue {
+			redacted[index] = Redact(part)
+		}
+		return redacted
+	}
+	return value
+}
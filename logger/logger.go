@@ -1,40 +1,177 @@
 package logger
 
 import (
+	"fmt"
 	log "github.com/op/go-logging"
+	"io"
 	stdlog "log"
+	"log/syslog"
 	"os"
+	"strings"
 )
 
 const (
 	LOGGER_NAME = "crane"
+
+	TEXT_FORMAT = "text"
+	JSON_FORMAT = "json"
+
+	DEFAULT_LEVEL  = "notice"
+	DEFAULT_FORMAT = TEXT_FORMAT
+
+	LOG_FILE_MODE = 0644
 )
 
 var logger = log.MustGetLogger(LOGGER_NAME)
 
+//Where and how crane logs are written.
+type Settings struct {
+	Level  string //critical, error, warning, notice, info or debug
+	Format string //text or json (one JSON object per line)
+	File   string //Additional log file, same format as the stderr output. Leave empty if not needed.
+	Syslog bool   //Send logs to the syslog as well.
+}
+
 //Customize logger
 func init() {
 
-	//Customize the output format
-	log.SetFormatter(log.MustStringFormatter("▶ %{level:.1s} 0x%{id:x}  %{message}"))
+	//Customize the output format, fields follow the message
+	log.SetFormatter(fieldsFormatter{log.MustStringFormatter("▶ %{level:.1s} 0x%{id:x}  %{message}")})
+
+	//Until the user settings are known log to the stderr only.
+	//Syslog is optional since it is not available in minimal containers and CI.
+	log.SetBackend(newTextBackend(os.Stderr, true))
+
+	//Default log level
+	log.SetLevel(log.NOTICE, LOGGER_NAME)
+}
+
+//Sets up log sinks and the log level for this run.
+//Failing to reach the syslog is only reported, it never stops crane.
+func Configure(settings Settings) error {
+
+	if len(settings.Level) == 0 {
+		settings.Level = DEFAULT_LEVEL
+	}
+	if len(settings.Format) == 0 {
+		settings.Format = DEFAULT_FORMAT
+	}
+
+	level, err := log.LogLevel(settings.Level)
+	if err != nil {
+		return fmt.Errorf("unknown log level %q, please use one of: critical, error, warning, notice, info, debug", settings.Level)
+	}
 
-	// Setup one stdout and one syslog backend.
-	logBackend := log.NewLogBackend(os.Stderr, "", stdlog.LstdFlags|stdlog.Lshortfile)
-	logBackend.Color = true
+	var backends []log.Backend
 
-	syslogBackend, err := log.NewSyslogBackend("")
+	stderrBackend, err := newBackend(settings.Format, os.Stderr, true)
 	if err != nil {
-		logger.Fatal("Failed to set up syslog backend:", err)
+		return err
 	}
+	backends = append(backends, stderrBackend)
 
-	// Combine them both into one log backend.
-	log.SetBackend(logBackend, syslogBackend)
+	if len(settings.File) > 0 {
+		file, err := os.OpenFile(settings.File, os.O_WRONLY|os.O_CREATE|os.O_APPEND, LOG_FILE_MODE)
+		if err != nil {
+			return fmt.Errorf("failed to open log file %q: %v", settings.File, err)
+		}
 
-	//Default log level
-	log.SetLevel(log.NOTICE, LOGGER_NAME)
+		fileBackend, err := newBackend(settings.Format, file, false)
+		if err != nil {
+			return err
+		}
+		backends = append(backends, fileBackend)
+	}
+
+	var syslogErr error
+	if settings.Syslog {
+		syslogWriter, err := syslog.New(syslog.LOG_INFO|syslog.LOG_USER, LOGGER_NAME)
+		if err != nil {
+			syslogErr = err
+		} else {
+			backends = append(backends, newSyslogBackend(syslogWriter))
+		}
+	}
+
+	log.SetBackend(backends...)
+	log.SetLevel(level, LOGGER_NAME)
+
+	if syslogErr != nil {
+		logger.Warning("Syslog is not available, logs will not be sent there: %v", syslogErr)
+	}
+
+	return nil
+}
+
+//Creates a backend writing records in a given format.
+func newBackend(format string, output *os.File, isStderr bool) (log.Backend, error) {
+
+	switch strings.ToLower(format) {
+	case TEXT_FORMAT:
+		return newTextBackend(output, isStderr), nil
+	case JSON_FORMAT:
+		return newJsonBackend(output), nil
+	}
+	return nil, fmt.Errorf("unknown log format %q, please use %q or %q", format, TEXT_FORMAT, JSON_FORMAT)
+}
+
+//Human readable backend, colored when writing to the terminal.
+func newTextBackend(output *os.File, colored bool) log.Backend {
+
+	flags := stdlog.LstdFlags
+	if colored {
+		flags = flags | stdlog.Lshortfile
+	}
+
+	backend := log.NewLogBackend(NewRedactingWriter(output), "", flags)
+	backend.Color = colored
+
+	return backend
 }
 
 //Get the instance...sorry, forgot it's go...value of type log.Logger.
 func GetLogger() *log.Logger {
 	return logger
 }
+
+//Formatter of the text format appending the current fields to every record.
+type fieldsFormatter struct {
+	formatter log.Formatter
+}
+
+func (formatter fieldsFormatter) Format(calldepth int, record *log.Record, output io.Writer) error {
+
+	if err := formatter.formatter.Format(calldepth+1, record, output); err != nil {
+		return err
+	}
+	_, err := io.WriteString(output, textFields())
+	return err
+}
+
+//Backend sending every record to the syslog with the priority matching its level.
+type syslogBackend struct {
+	writer *syslog.Writer
+}
+
+func newSyslogBackend(writer *syslog.Writer) log.Backend {
+	return syslogBackend{writer: writer}
+}
+
+func (backend syslogBackend) Log(level log.Level, calldepth int, record *log.Record) error {
+
+	message := Redact(record.Message() + textFields())
+
+	switch level {
+	case log.CRITICAL:
+		return backend.writer.Crit(message)
+	case log.ERROR:
+		return backend.writer.Err(message)
+	case log.WARNING:
+		return backend.writer.Warning(message)
+	case log.NOTICE:
+		return backend.writer.Notice(message)
+	case log.INFO:
+		return backend.writer.Info(message)
+	}
+	return backend.writer.Debug(message)
+}
@@ -0,0 +1,76 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	log "github.com/op/go-logging"
+	"strings"
+	"testing"
+)
+
+func TestJsonBackend_fieldsAndRedaction(t *testing.T) {
+
+	var output bytes.Buffer
+
+	log.SetBackend(newJsonBackend(&output))
+	defer Configure(Settings{})
+
+	RegisterSecret("orobix2013")
+	SetField(CONTAINER_FIELD, "firstContainer")
+	SetField(DOCKER_ARGV_FIELD, []string{"sudo", "docker", "run", "-e=PASSWORD=orobix2013"})
+	defer RemoveField(CONTAINER_FIELD)
+	defer RemoveField(DOCKER_ARGV_FIELD)
+
+	logger.Notice("Logging in with password %s", "orobix2013")
+
+	var entry map[string]interface{}
+	if err := json.Unmarshal(output.Bytes(), &entry); err != nil {
+		t.Fatalf("Log line is not valid JSON: %q (%v)", output.String(), err)
+	}
+
+	if entry["container"] != "firstContainer" {
+		t.Errorf("container field = %v; expected \"firstContainer\"", entry["container"])
+	}
+	if entry["level"] != "NOTICE" {
+		t.Errorf("level = %v; expected \"NOTICE\"", entry["level"])
+	}
+	if strings.Contains(output.String(), "orobix2013") {
+		t.Errorf("Secret leaked into the log: %q", output.String())
+	}
+}
+
+func TestConfigure_invalidSettings(t *testing.T) {
+
+	defer Configure(Settings{})
+
+	if err := Configure(Settings{Level: "loud"}); err == nil {
+		t.Error("Unknown log level should be rejected")
+	}
+	if err := Configure(Settings{Format: "xml"}); err == nil {
+		t.Error("Unknown log format should be rejected")
+	}
+}
+
+func TestScopeField_textFormat(t *testing.T) {
+
+	var output bytes.Buffer
+
+	log.SetBackend(log.NewLogBackend(&output, "", 0))
+	defer Configure(Settings{})
+
+	restore := ScopeField(CONTAINER_FIELD, "firstContainer")
+	logger.Notice("Starting container")
+	restore()
+	logger.Notice("Finished")
+
+	lines := strings.Split(strings.TrimSpace(output.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Unexpected log output: %q", output.String())
+	}
+	if !strings.Contains(lines[0], `container="firstContainer"`) {
+		t.Errorf("Field missing from the text record: %q", lines[0])
+	}
+	if strings.Contains(lines[1], CONTAINER_FIELD) {
+		t.Errorf("Scoped field stuck to a later record: %q", lines[1])
+	}
+}
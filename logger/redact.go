@@ -0,0 +1,65 @@
+package logger
+
+import (
+	"io"
+	"strings"
+	"sync"
+)
+
+//Text printed instead of a secret value.
+const REDACTED = "******"
+
+//Values (passwords etc.) that must never appear in logs or error messages.
+var (
+	secrets      []string
+	secretsMutex sync.RWMutex
+)
+
+//Writer that hides all registered secrets before passing the text on.
+type redactingWriter struct {
+	writer io.Writer
+}
+
+//Registers a value that will be redacted from every log line from now on.
+func RegisterSecret(value string) {
+
+	if len(value) == 0 {
+		return
+	}
+
+	secretsMutex.Lock()
+	defer secretsMutex.Unlock()
+
+	for _, secret := range secrets {
+		if secret == value {
+			return
+		}
+	}
+	secrets = append(secrets, value)
+}
+
+//Replaces all registered secrets in a message.
+func Redact(message string) string {
+
+	secretsMutex.RLock()
+	defer secretsMutex.RUnlock()
+
+	for _, secret := range secrets {
+		message = strings.Replace(message, secret, REDACTED, -1)
+	}
+	return message
+}
+
+//Wraps a writer so registered secrets are redacted from everything written to it.
+func NewRedactingWriter(writer io.Writer) io.Writer {
+	return &redactingWriter{writer: writer}
+}
+
+func (redacting *redactingWriter) Write(bytes []byte) (int, error) {
+
+	if _, err := io.WriteString(redacting.writer, Redact(string(bytes))); err != nil {
+		return 0, err
+	}
+	//Report the original length, otherwise callers treat the shorter write as an error
+	return len(bytes), nil
+}
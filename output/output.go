@@ -0,0 +1,226 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	log "github.com/SnowRipple/crane/logger"
+	"gopkg.in/yaml.v2"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+/*
+ Output formats
+*/
+const (
+	TEXT_FORMAT = "text"
+	JSON_FORMAT = "json"
+	YAML_FORMAT = "yaml"
+
+	//Bumped whenever the result schema changes in an incompatible way.
+	SCHEMA_VERSION = 1
+)
+
+/*
+ Actions reported in results
+*/
+const (
+	START_ACTION    = "start"
+	RUN_ACTION      = "run"
+	FREEZE_ACTION   = "freeze"
+	BUILD_ACTION    = "build"
+	PULL_ACTION     = "pull"
+	DESTROY_ACTION  = "destroy"
+	STATUS_ACTION   = "status"
+	SNAPSHOT_ACTION = "snapshot"
+	ROLLBACK_ACTION = "rollback"
+)
+
+var logger = log.GetLogger()
+
+//Result of a single crane invocation, printed on the stdout in json or yaml format.
+type Result struct {
+	SchemaVersion   int       `json:"schema_version" yaml:"schema_version"`
+	Command         string    `json:"command" yaml:"command"`
+	Success         bool      `json:"success" yaml:"success"`
+	ExitCode        int       `json:"exit_code" yaml:"exit_code"`
+	DurationSeconds float64   `json:"duration_seconds" yaml:"duration_seconds"`
+	Error           string    `json:"error,omitempty" yaml:"error,omitempty"`
+	Actions         []*Action `json:"actions" yaml:"actions"`
+}
+
+//Single operation performed by a command on a container or an image.
+type Action struct {
+	Action          string   `json:"action" yaml:"action"`
+	Container       string   `json:"container,omitempty" yaml:"container,omitempty"`
+	Image           string   `json:"image,omitempty" yaml:"image,omitempty"`
+	ContainerID     string   `json:"container_id,omitempty" yaml:"container_id,omitempty"`
+	ImageID         string   `json:"image_id,omitempty" yaml:"image_id,omitempty"`
+	IP              string   `json:"ip,omitempty" yaml:"ip,omitempty"`
+	State           string   `json:"state,omitempty" yaml:"state,omitempty"`
+	Limits          string   `json:"limits,omitempty" yaml:"limits,omitempty"`
+	Ports           []string `json:"ports,omitempty" yaml:"ports,omitempty"`
+	ExitCode        int      `json:"exit_code" yaml:"exit_code"`
+	DurationSeconds float64  `json:"duration_seconds" yaml:"duration_seconds"`
+	Error           string   `json:"error,omitempty" yaml:"error,omitempty"`
+
+	started  time.Time
+	finished bool
+}
+
+var (
+	format  = TEXT_FORMAT
+	result  = &Result{SchemaVersion: SCHEMA_VERSION, Actions: []*Action{}}
+	started = time.Now()
+	printed = false
+	mutex   sync.Mutex
+)
+
+//Chooses the output format.In the text format (default) no result is printed.
+func SetFormat(chosenFormat string) error {
+
+	switch strings.ToLower(chosenFormat) {
+	case "", TEXT_FORMAT:
+		format = TEXT_FORMAT
+	case JSON_FORMAT:
+		format = JSON_FORMAT
+	case YAML_FORMAT:
+		format = YAML_FORMAT
+	default:
+		return fmt.Errorf("unknown output format %q, please use %q, %q or %q", chosenFormat, TEXT_FORMAT, JSON_FORMAT, YAML_FORMAT)
+	}
+	return nil
+}
+
+//Checks if the result will be printed in a machine readable format.
+func IsMachineReadable() bool {
+	return format != TEXT_FORMAT
+}
+
+//Where commands executed inside containers should write their output.
+//In the machine readable mode the stdout is reserved for the result so the output goes to the stderr.
+func Stdout() io.Writer {
+
+	if IsMachineReadable() {
+		return os.Stderr
+	}
+	return os.Stdout
+}
+
+//Writer following Stdout, it can be handed out before the output format is chosen.
+var Console io.Writer = consoleWriter{}
+
+type consoleWriter struct{}
+
+func (consoleWriter) Write(bytes []byte) (int, error) {
+	return Stdout().Write(bytes)
+}
+
+//Starts recording the result of a given crane command.
+func Begin(command string) {
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	result.Command = command
+	started = time.Now()
+}
+
+//Starts recording a new action of the current command.
+func StartAction(action, containerName string) *Action {
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	newAction := &Action{Action: action, Container: containerName, started: time.Now()}
+	result.Actions = append(result.Actions, newAction)
+
+	return newAction
+}
+
+//Marks the action as done.
+func (action *Action) Finish() {
+	action.DurationSeconds = time.Since(action.started).Seconds()
+	action.finished = true
+}
+
+//Marks the action as failed.
+func (action *Action) Fail(exitCode int, message string) {
+	action.ExitCode = exitCode
+	action.Error = log.Redact(message)
+	action.Finish()
+}
+
+//Marks all actions that are still in progress as failed with a given error.
+func FailPending(exitCode int, message string) {
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	failPending(exitCode, message)
+}
+
+func failPending(exitCode int, message string) {
+
+	for _, action := range result.Actions {
+		if !action.finished {
+			action.Fail(exitCode, message)
+		}
+	}
+}
+
+//Records the error that ended the command.
+func SetError(message string) {
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	result.Error = log.Redact(message)
+}
+
+//Prints the result of the command (only in the machine readable mode).The result is printed only once.
+//If no error message is given the one recorded with SetError is used.
+func Finish(exitCode int, errorMessage string) {
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	if !IsMachineReadable() || printed {
+		return
+	}
+	printed = true
+
+	result.ExitCode = exitCode
+	result.Success = exitCode == 0
+	if len(errorMessage) > 0 {
+		result.Error = log.Redact(errorMessage)
+	}
+	result.DurationSeconds = time.Since(started).Seconds()
+
+	//Actions interrupted by an error are marked as failed
+	if exitCode != 0 {
+		failPending(exitCode, result.Error)
+	}
+
+	var (
+		bytes []byte
+		err   error
+	)
+
+	if format == YAML_FORMAT {
+		bytes, err = yaml.Marshal(result)
+	} else {
+		bytes, err = json.MarshalIndent(result, "", "  ")
+		bytes = append(bytes, '\n')
+	}
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to print the result due to error: %v\n", err)
+		return
+	}
+
+	os.Stdout.Write(bytes)
+}
@@ -0,0 +1,220 @@
+package secret
+
+import (
+	"code.google.com/p/go.crypto/scrypt"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/SnowRipple/crane/constants"
+	"github.com/SnowRipple/crane/dryrun"
+	log "github.com/SnowRipple/crane/logger"
+	"github.com/SnowRipple/crane/terminal"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+/*
+ Keystore encryption parameters
+*/
+const (
+	SALT_SIZE      = 16
+	KEY_SIZE       = 32 //AES-256
+	SCRYPT_N       = 32768
+	SCRYPT_R       = 8
+	SCRYPT_P       = 1
+	FILE_MODE      = 0600
+	DIRECTORY_MODE = 0700
+)
+
+//Local keystore holding secrets encrypted with a passphrase.
+//The file consists of the salt, the nonce and the AES-GCM encrypted JSON map of secrets.
+type Keystore struct {
+	path       string
+	passphrase []byte
+	secrets    map[string]string
+}
+
+//Opens the keystore of the current user, asking for the passphrase if needed.If the keystore does not exist yet an empty one is returned.
+func OpenKeystore() (*Keystore, error) {
+
+	path, err := keystorePath()
+	if err != nil {
+		return nil, err
+	}
+
+	passphrase, err := readPassphrase()
+	if err != nil {
+		return nil, err
+	}
+
+	return OpenKeystoreFile(path, passphrase)
+}
+
+//Opens the keystore stored in a given file using a given passphrase.If the file does not exist yet an empty keystore is returned.
+func OpenKeystoreFile(path string, passphrase []byte) (*Keystore, error) {
+
+	keystore := &Keystore{path: path, passphrase: passphrase, secrets: map[string]string{}}
+
+	content, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		logger.Debug("Keystore %q does not exist yet, starting with an empty one.", path)
+		return keystore, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	plaintext, err := decrypt(content, passphrase)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt keystore %q (wrong passphrase?): %v", path, err)
+	}
+
+	if err := json.Unmarshal(plaintext, &keystore.secrets); err != nil {
+		return nil, fmt.Errorf("keystore %q is corrupted: %v", path, err)
+	}
+
+	return keystore, nil
+}
+
+//Returns the value of a secret.
+func (keystore *Keystore) Get(name string) (value string, exists bool) {
+	value, exists = keystore.secrets[name]
+	return value, exists
+}
+
+//Sets the value of a secret.The keystore has to be saved afterwards.
+func (keystore *Keystore) Set(name, value string) {
+	keystore.secrets[name] = value
+}
+
+//Returns sorted names of all secrets.
+func (keystore *Keystore) Names() []string {
+
+	var names []string
+	for name := range keystore.secrets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+//Encrypts and writes the keystore to the disk.
+func (keystore *Keystore) Save() error {
+
+	plaintext, err := json.Marshal(keystore.secrets)
+	if err != nil {
+		return err
+	}
+
+	content, err := encrypt(plaintext, keystore.passphrase)
+	if err != nil {
+		return err
+	}
+
+	if dryrun.Enabled() {
+		dryrun.Print("write %s (%d encrypted secrets)", keystore.path, len(keystore.secrets))
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(keystore.path), DIRECTORY_MODE); err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(keystore.path, content, FILE_MODE)
+}
+
+//Location of the keystore of the current user.
+func keystorePath() (string, error) {
+
+	if path := os.Getenv(constants.SECRETS_FILE_VARIABLE); len(path) > 0 {
+		return path, nil
+	}
+
+	home := os.Getenv("HOME")
+	if len(home) == 0 {
+		return "", errors.New("HOME is not set, can't locate the keystore")
+	}
+	return filepath.Join(home, constants.SECRETS_FILE), nil
+}
+
+//Reads the keystore passphrase from the environment or asks the user for it.The passphrase is redacted from logs.
+func readPassphrase() ([]byte, error) {
+
+	passphrase := os.Getenv(constants.SECRETS_PASSPHRASE_VARIABLE)
+
+	if len(passphrase) == 0 {
+		if !terminal.IsTerminal() {
+			return nil, fmt.Errorf("no terminal to ask for the keystore passphrase, please set %s", constants.SECRETS_PASSPHRASE_VARIABLE)
+		}
+
+		var err error
+		if passphrase, err = terminal.ReadHiddenInput("Keystore passphrase: "); err != nil {
+			return nil, err
+		}
+	}
+
+	log.RegisterSecret(passphrase)
+	return []byte(passphrase), nil
+}
+
+//Derives the encryption key from the passphrase.
+func deriveKey(passphrase, salt []byte) (cipher.AEAD, error) {
+
+	key, err := scrypt.Key(passphrase, salt, SCRYPT_N, SCRYPT_R, SCRYPT_P, KEY_SIZE)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+func encrypt(plaintext, passphrase []byte) ([]byte, error) {
+
+	salt := make([]byte, SALT_SIZE)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+
+	aead, err := deriveKey(passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	content := append(salt, nonce...)
+	return aead.Seal(content, nonce, plaintext, nil), nil
+}
+
+func decrypt(content, passphrase []byte) ([]byte, error) {
+
+	if len(content) < SALT_SIZE {
+		return nil, errors.New("keystore is too short")
+	}
+
+	salt := content[:SALT_SIZE]
+	aead, err := deriveKey(passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(content) < SALT_SIZE+aead.NonceSize() {
+		return nil, errors.New("keystore is too short")
+	}
+
+	nonce := content[SALT_SIZE : SALT_SIZE+aead.NonceSize()]
+	return aead.Open(nil, nonce, content[SALT_SIZE+aead.NonceSize():], nil)
+}
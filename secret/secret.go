@@ -0,0 +1,104 @@
+package secret
+
+import (
+	"github.com/SnowRipple/crane/constants"
+	"github.com/SnowRipple/crane/exit"
+	log "github.com/SnowRipple/crane/logger"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+)
+
+var logger = log.GetLogger()
+
+//Secrets resolved during this crane invocation so the keystore is opened at most once.
+var (
+	resolved      = map[string]string{}
+	resolvedMutex sync.Mutex
+	keystore      *Keystore
+)
+
+//Checks if a Cranefile value refers to a secret instead of holding it in plain text.
+func IsReference(value string) bool {
+	return strings.HasPrefix(value, constants.SECRET_PREFIX) || strings.HasPrefix(value, constants.ENV_PREFIX) || strings.HasPrefix(value, constants.FILE_PREFIX)
+}
+
+//Resolves a Cranefile value that may refer to a secret:
+//->"secret:<name>" - secret stored in the local encrypted keystore (see crane secret).
+//->"env:<VARIABLE>" - value of an environment variable.
+//->"file:<path>" - content of a file.
+//Any other value is returned as it is.The resolved value is redacted from all logs.
+func Resolve(value string) (string, error) {
+
+	if !IsReference(value) {
+		log.RegisterSecret(value)
+		return value, nil
+	}
+
+	resolvedMutex.Lock()
+	defer resolvedMutex.Unlock()
+
+	if secret, exists := resolved[value]; exists {
+		return secret, nil
+	}
+
+	var (
+		secret string
+		err    error
+	)
+
+	switch {
+	case strings.HasPrefix(value, constants.SECRET_PREFIX):
+		secret, err = resolveFromKeystore(strings.TrimPrefix(value, constants.SECRET_PREFIX))
+	case strings.HasPrefix(value, constants.ENV_PREFIX):
+		secret, err = resolveFromEnvironment(strings.TrimPrefix(value, constants.ENV_PREFIX))
+	case strings.HasPrefix(value, constants.FILE_PREFIX):
+		secret, err = resolveFromFile(strings.TrimPrefix(value, constants.FILE_PREFIX))
+	}
+
+	if err != nil {
+		return "", err
+	}
+
+	log.RegisterSecret(secret)
+	resolved[value] = secret
+
+	logger.Debug("Resolved secret reference %q", value)
+	return secret, nil
+}
+
+func resolveFromKeystore(name string) (string, error) {
+
+	if keystore == nil {
+		openedKeystore, err := OpenKeystore()
+		if err != nil {
+			return "", exit.Errorf(exit.CONFIG_ERROR, "Failed to open the keystore due to error: %v", err)
+		}
+		keystore = openedKeystore
+	}
+
+	secret, exists := keystore.Get(name)
+	if !exists {
+		return "", exit.Errorf(exit.CONFIG_ERROR, "Secret %q does not exist in the keystore.Please add it with \"crane secret set %s\".", name, name)
+	}
+	return secret, nil
+}
+
+func resolveFromEnvironment(variable string) (string, error) {
+
+	secret, exists := os.LookupEnv(variable)
+	if !exists {
+		return "", exit.Errorf(exit.CONFIG_ERROR, "Environment variable %q referenced in the Cranefile is not set.Please correct.", variable)
+	}
+	return secret, nil
+}
+
+func resolveFromFile(filename string) (string, error) {
+
+	content, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return "", exit.Errorf(exit.CONFIG_ERROR, "Failed to read secret file %q due to error: %v", filename, err)
+	}
+	return strings.TrimSpace(string(content)), nil
+}
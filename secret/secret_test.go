@@ -0,0 +1,101 @@
+package secret
+
+import (
+	"bytes"
+	"github.com/SnowRipple/crane/constants"
+	"github.com/SnowRipple/crane/exit"
+	log "github.com/SnowRipple/crane/logger"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestKeystore_roundTrip(t *testing.T) {
+
+	directory, err := ioutil.TempDir("", "crane")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(directory)
+
+	path := filepath.Join(directory, "secrets")
+
+	keystore, err := OpenKeystoreFile(path, []byte("passphrase"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	keystore.Set("db_root", "orobix2013")
+	if err := keystore.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(content, []byte("orobix2013")) {
+		t.Fatalf("Keystore is not encrypted: %q", content)
+	}
+
+	reopened, err := OpenKeystoreFile(path, []byte("passphrase"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if value, exists := reopened.Get("db_root"); !exists || value != "orobix2013" {
+		t.Errorf("Get(\"db_root\") = %q, %t; expected \"orobix2013\", true", value, exists)
+	}
+
+	if _, err := OpenKeystoreFile(path, []byte("wrong")); err == nil {
+		t.Error("Opening the keystore with a wrong passphrase should fail")
+	}
+}
+
+func TestResolve(t *testing.T) {
+
+	os.Setenv("CRANE_TEST_PASSWORD", "fromEnvironment")
+	defer os.Unsetenv("CRANE_TEST_PASSWORD")
+
+	file, err := ioutil.TempFile("", "crane")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(file.Name())
+	file.WriteString("fromFile\n")
+	file.Close()
+
+	cases := map[string]string{
+		"plain":                   "plain",
+		"env:CRANE_TEST_PASSWORD": "fromEnvironment",
+		"file:" + file.Name():     "fromFile",
+	}
+
+	for reference, expected := range cases {
+		if value, err := Resolve(reference); err != nil || value != expected {
+			t.Errorf("Resolve(%q) = %q, %v; expected %q", reference, value, err, expected)
+		}
+	}
+}
+
+func TestResolve_missingEnvironmentVariable(t *testing.T) {
+
+	os.Unsetenv("CRANE_TEST_MISSING")
+
+	if _, err := Resolve("env:CRANE_TEST_MISSING"); exit.Code(err) != exit.CONFIG_ERROR {
+		t.Errorf("Resolve of a missing variable returned %v; expected a config error", err)
+	}
+}
+
+func TestReadPassphrase_redacted(t *testing.T) {
+
+	t.Setenv(constants.SECRETS_PASSPHRASE_VARIABLE, "keystore-passphrase-2013")
+
+	passphrase, err := readPassphrase()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if redacted := log.Redact("Opening the keystore with " + string(passphrase)); strings.Contains(redacted, string(passphrase)) {
+		t.Errorf("Passphrase leaked into the log: %q", redacted)
+	}
+}
@@ -0,0 +1,23 @@
+package ssh
+
+import (
+	"net"
+	"strconv"
+)
+
+//Address inside the container the forwarded connections are sent to.
+const FORWARD_HOST = "127.0.0.1"
+
+//Opens a tunneled connection to a port inside a container over the container's (pooled) ssh connection.
+func DialContainerPort(sshAddress, username, passwordString string, containerPort int) (net.Conn, error) {
+
+	client, err := defaultPool.Get(sshAddress, username, passwordString)
+	if err != nil {
+		return nil, err
+	}
+
+	address := net.JoinHostPort(FORWARD_HOST, strconv.Itoa(containerPort))
+	logger.Debug("Opening ssh tunnel to %q inside %q", address, sshAddress)
+
+	return client.Dial("tcp", address)
+}
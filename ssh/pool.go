@@ -0,0 +1,146 @@
+package ssh
+
+import (
+	"code.google.com/p/go.crypto/ssh"
+	"sync"
+)
+
+//Pool of ssh connections to containers.
+//Every connection is opened once and then shared by all sessions (commands, shells, sftp) that need it,
+//so a single crane invocation opens at most one connection per container.
+type ConnectionPool struct {
+	mutex       sync.Mutex
+	connections map[string]*ssh.ClientConn
+
+	//Opening, using and closing connections, replaced in tests
+	dial       func(sshAddress, username, passwordString string) (*ssh.ClientConn, error)
+	newSession func(client *ssh.ClientConn) (*ssh.Session, error)
+	close      func(client *ssh.ClientConn) error
+}
+
+//Pool used by crane commands during a single invocation.
+var defaultPool = NewConnectionPool()
+
+//Creates a new empty connection pool.
+func NewConnectionPool() *ConnectionPool {
+
+	return &ConnectionPool{
+		connections: map[string]*ssh.ClientConn{},
+		dial:        dial,
+		newSession:  newSession,
+		close:       func(client *ssh.ClientConn) error { return client.Close() },
+	}
+}
+
+//Returns the pooled connection to a container, dialing it first if needed.
+//Dialing happens outside of the lock so an unreachable container does not hold up connections to the others.
+func (pool *ConnectionPool) Get(sshAddress, username, passwordString string) (*ssh.ClientConn, error) {
+
+	key := connectionKey(sshAddress, username)
+
+	if client, exists := pool.lookup(key); exists {
+		logger.Debug("Reusing ssh connection to %q", key)
+		return client, nil
+	}
+
+	logger.Debug("Opening new ssh connection to %q", key)
+	client, err := pool.dial(sshAddress, username, passwordString)
+	if err != nil {
+		return nil, err
+	}
+
+	pool.mutex.Lock()
+	defer pool.mutex.Unlock()
+
+	//Another session may have connected in the meantime, its connection is kept and the new one closed
+	if pooled, exists := pool.connections[key]; exists {
+		logger.Debug("Ssh connection to %q was opened concurrently, reusing it", key)
+		pool.close(client)
+		return pooled, nil
+	}
+	pool.connections[key] = client
+
+	return client, nil
+}
+
+//Returns the pooled connection stored under a key.
+func (pool *ConnectionPool) lookup(key string) (*ssh.ClientConn, bool) {
+
+	pool.mutex.Lock()
+	defer pool.mutex.Unlock()
+
+	client, exists := pool.connections[key]
+	return client, exists
+}
+
+//Opens a new session on the pooled connection to a container.
+//If the pooled connection is broken (e.g. the container was restarted) it is dropped and dialed again once.
+func (pool *ConnectionPool) NewSession(sshAddress, username, passwordString string) (*ssh.Session, error) {
+
+	client, err := pool.Get(sshAddress, username, passwordString)
+	if err != nil {
+		return nil, err
+	}
+
+	session, err := pool.newSession(client)
+	if err == nil {
+		return session, nil
+	}
+
+	logger.Debug("Pooled ssh connection to %q is broken, reconnecting: %v", sshAddress, err)
+	pool.forget(connectionKey(sshAddress, username), client)
+
+	client, err = pool.Get(sshAddress, username, passwordString)
+	if err != nil {
+		return nil, err
+	}
+	return pool.newSession(client)
+}
+
+//Closes and forgets the pooled connection to a container.
+func (pool *ConnectionPool) Remove(sshAddress, username string) {
+
+	pool.mutex.Lock()
+	defer pool.mutex.Unlock()
+
+	key := connectionKey(sshAddress, username)
+
+	if client, exists := pool.connections[key]; exists {
+		pool.close(client)
+		delete(pool.connections, key)
+	}
+}
+
+//Closes a broken connection and forgets it unless another session has replaced it already.
+func (pool *ConnectionPool) forget(key string, client *ssh.ClientConn) {
+
+	pool.mutex.Lock()
+	defer pool.mutex.Unlock()
+
+	if pool.connections[key] == client {
+		delete(pool.connections, key)
+	}
+	pool.close(client)
+}
+
+//Closes all pooled connections.
+func (pool *ConnectionPool) CloseAll() {
+
+	pool.mutex.Lock()
+	defer pool.mutex.Unlock()
+
+	for key, client := range pool.connections {
+		logger.Debug("Closing ssh connection to %q", key)
+		pool.close(client)
+		delete(pool.connections, key)
+	}
+}
+
+//Closes all connections opened by crane commands.Should be called once crane is done.
+func CloseConnections() {
+	defaultPool.CloseAll()
+}
+
+func connectionKey(sshAddress, username string) string {
+	return username + "@" + sshAddress
+}
@@ -0,0 +1,153 @@
+package ssh
+
+import (
+	"code.google.com/p/go.crypto/ssh"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+//Pool whose connections are fakes: dials are counted, sessions fail on connections marked as broken.
+type fakeConnections struct {
+	mutex  sync.Mutex
+	dials  map[string]int
+	broken map[*ssh.ClientConn]bool
+	closed map[*ssh.ClientConn]bool
+}
+
+func newFakePool() (*ConnectionPool, *fakeConnections) {
+
+	fake := &fakeConnections{dials: map[string]int{}, broken: map[*ssh.ClientConn]bool{}, closed: map[*ssh.ClientConn]bool{}}
+
+	pool := NewConnectionPool()
+	pool.dial = func(sshAddress, username, passwordString string) (*ssh.ClientConn, error) {
+		fake.mutex.Lock()
+		defer fake.mutex.Unlock()
+
+		fake.dials[sshAddress]++
+		return &ssh.ClientConn{}, nil
+	}
+	pool.newSession = func(client *ssh.ClientConn) (*ssh.Session, error) {
+		fake.mutex.Lock()
+		defer fake.mutex.Unlock()
+
+		if fake.broken[client] {
+			return nil, errors.New("connection reset by peer")
+		}
+		return &ssh.Session{}, nil
+	}
+	pool.close = func(client *ssh.ClientConn) error {
+		fake.mutex.Lock()
+		defer fake.mutex.Unlock()
+
+		fake.closed[client] = true
+		return nil
+	}
+	return pool, fake
+}
+
+func TestConnectionPool_reuse(t *testing.T) {
+
+	pool, fake := newFakePool()
+
+	first, err := pool.Get("172.17.0.2", "root", "orobix2013")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	second, _ := pool.Get("172.17.0.2", "root", "orobix2013")
+	other, _ := pool.Get("172.17.0.3", "root", "orobix2013")
+
+	if first != second {
+		t.Errorf("Connection to the same container was not reused")
+	}
+	if first == other {
+		t.Errorf("Containers share a connection")
+	}
+	if fake.dials["172.17.0.2"] != 1 {
+		t.Errorf("Container dialed %d times; expected once", fake.dials["172.17.0.2"])
+	}
+}
+
+func TestConnectionPool_remove(t *testing.T) {
+
+	pool, fake := newFakePool()
+
+	client, _ := pool.Get("172.17.0.2", "root", "orobix2013")
+	pool.Remove("172.17.0.2", "root")
+	pool.Remove("172.17.0.4", "root") //Never connected, nothing happens
+
+	if !fake.closed[client] {
+		t.Errorf("Removed connection was not closed")
+	}
+	if redialed, _ := pool.Get("172.17.0.2", "root", "orobix2013"); redialed == client {
+		t.Errorf("Removed connection was reused")
+	}
+	if fake.dials["172.17.0.2"] != 2 {
+		t.Errorf("Container dialed %d times; expected twice", fake.dials["172.17.0.2"])
+	}
+}
+
+func TestConnectionPool_newSessionRedialsOnce(t *testing.T) {
+
+	pool, fake := newFakePool()
+
+	broken, _ := pool.Get("172.17.0.2", "root", "orobix2013")
+	fake.broken[broken] = true
+
+	if _, err := pool.NewSession("172.17.0.2", "root", "orobix2013"); err != nil {
+		t.Fatalf("Session on the redialed connection failed: %v", err)
+	}
+	if !fake.closed[broken] {
+		t.Errorf("Broken connection was not closed")
+	}
+	if fake.dials["172.17.0.2"] != 2 {
+		t.Errorf("Container dialed %d times; expected twice", fake.dials["172.17.0.2"])
+	}
+
+	//A connection that breaks again right away is reported instead of redialed forever
+	pool.dial = func(sshAddress, username, passwordString string) (*ssh.ClientConn, error) {
+		client := &ssh.ClientConn{}
+		fake.broken[client] = true
+		return client, nil
+	}
+	pool.Remove("172.17.0.2", "root")
+	if _, err := pool.NewSession("172.17.0.2", "root", "orobix2013"); err == nil {
+		t.Errorf("Session on a broken connection succeeded")
+	}
+}
+
+func TestConnectionPool_dialOutsideLock(t *testing.T) {
+
+	pool, _ := newFakePool()
+	fakeDial := pool.dial
+
+	unreachable := make(chan struct{})
+	defer close(unreachable)
+
+	pool.dial = func(sshAddress, username, passwordString string) (*ssh.ClientConn, error) {
+		if sshAddress == "10.0.0.99" {
+			<-unreachable
+			return nil, errors.New("i/o timeout")
+		}
+		return fakeDial(sshAddress, username, passwordString)
+	}
+
+	go pool.Get("10.0.0.99", "root", "orobix2013")
+	time.Sleep(10 * time.Millisecond) //Let the dial to the unreachable container start
+
+	connected := make(chan error, 1)
+	go func() {
+		_, err := pool.Get("172.17.0.2", "root", "orobix2013")
+		connected <- err
+	}()
+
+	select {
+	case err := <-connected:
+		if err != nil {
+			t.Errorf("Unexpected error: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Connecting to a reachable container waited for an unreachable one")
+	}
+}
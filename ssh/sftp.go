@@ -0,0 +1,199 @@
+package ssh
+
+import (
+	"code.google.com/p/go.crypto/ssh"
+	"github.com/SnowRipple/crane/dryrun"
+	"github.com/SnowRipple/crane/exit"
+	"github.com/SnowRipple/crane/utils"
+	"github.com/pkg/sftp"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+//Copies a file or a directory (recursively) from a container to the host over sftp.
+func SftpDownload(sshAddress, username, passwordString, containerPath, hostPath string) error {
+
+	logger.Debug("Downloading %q from %q into %q", containerPath, sshAddress, hostPath)
+
+	if dryrun.Enabled() {
+		dryrun.Print("sftp get -r %s@%s:%s %s", username, sshAddress, containerPath, hostPath)
+		return nil
+	}
+
+	sftpClient, err := newPooledSftpClient(sshAddress, username, passwordString)
+	if err != nil {
+		return err
+	}
+	defer sftpClient.Close()
+
+	sourceInfo, err := sftpClient.Stat(containerPath)
+	if err != nil {
+		return exit.Errorf(exit.CONNECTION_ERROR, "Failed to access %q inside the container due to error: %v", containerPath, err)
+	}
+
+	//Copy into the directory if it already exists, just like cp does
+	if hostInfo, err := os.Stat(hostPath); err == nil && hostInfo.IsDir() {
+		hostPath = filepath.Join(hostPath, path.Base(containerPath))
+	}
+
+	if !sourceInfo.IsDir() {
+		return downloadFile(sftpClient, containerPath, hostPath, sourceInfo)
+	}
+
+	walker := sftpClient.Walk(containerPath)
+	for walker.Step() {
+		if err := walker.Err(); err != nil {
+			return exit.Errorf(exit.CONNECTION_ERROR, "Failed to walk %q inside the container due to error: %v", walker.Path(), err)
+		}
+
+		target := filepath.Join(hostPath, filepath.FromSlash(strings.TrimPrefix(walker.Path(), containerPath)))
+
+		if walker.Stat().IsDir() {
+			if err := os.MkdirAll(target, walker.Stat().Mode().Perm()); err != nil {
+				return exit.Errorf(exit.FILE_ERROR, "Failed to create directory %q due to error: %v", target, err)
+			}
+			continue
+		}
+		if err := downloadFile(sftpClient, walker.Path(), target, walker.Stat()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//Copies a file or a directory (recursively) from the host to a container over sftp.
+func SftpUpload(sshAddress, username, passwordString, hostPath, containerPath string) error {
+
+	logger.Debug("Uploading %q into %q on %q", hostPath, containerPath, sshAddress)
+
+	if dryrun.Enabled() {
+		dryrun.Print("sftp put -r %s %s@%s:%s", hostPath, username, sshAddress, containerPath)
+		return nil
+	}
+
+	sftpClient, err := newPooledSftpClient(sshAddress, username, passwordString)
+	if err != nil {
+		return err
+	}
+	defer sftpClient.Close()
+
+	sourceInfo, err := os.Stat(hostPath)
+	if err != nil {
+		return exit.Errorf(exit.FILE_ERROR, "Failed to access %q due to error: %v", hostPath, err)
+	}
+
+	//Copy into the directory if it already exists, just like cp does
+	if containerInfo, err := sftpClient.Stat(containerPath); err == nil && containerInfo.IsDir() {
+		containerPath = path.Join(containerPath, filepath.Base(hostPath))
+	}
+
+	if !sourceInfo.IsDir() {
+		return uploadFile(sftpClient, hostPath, containerPath, sourceInfo)
+	}
+
+	err = filepath.Walk(hostPath, func(currentPath string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		relativePath, err := filepath.Rel(hostPath, currentPath)
+		if err != nil {
+			return err
+		}
+		target := path.Join(containerPath, filepath.ToSlash(relativePath))
+
+		if info.IsDir() {
+			if _, err := sftpClient.Stat(target); err != nil {
+				logger.Debug("Creating directory %q inside the container", target)
+				return sftpClient.Mkdir(target)
+			}
+			return nil
+		}
+		return uploadFile(sftpClient, currentPath, target, info)
+	})
+
+	if err != nil {
+		if _, ok := err.(*exit.Error); ok {
+			return err
+		}
+		return exit.Errorf(exit.CONNECTION_ERROR, "Failed to upload %q into the container due to error: %v", hostPath, err)
+	}
+	return nil
+}
+
+//Starts the sftp subsystem on the pooled ssh connection to a container.
+func newPooledSftpClient(sshAddress, username, passwordString string) (*sftp.Client, error) {
+
+	client, err := defaultPool.Get(sshAddress, username, passwordString)
+	if err != nil {
+		return nil, err
+	}
+	return newSftpClient(client)
+}
+
+//Starts the sftp subsystem on an existing ssh connection.
+func newSftpClient(client *ssh.ClientConn) (*sftp.Client, error) {
+
+	sftpClient, err := sftp.NewClient(client)
+	if err != nil {
+		return nil, exit.Errorf(exit.CONNECTION_ERROR, "Failed to start sftp session due to error: %v", err)
+	}
+	return sftpClient, nil
+}
+
+//Copies a single file from a container to the host.
+func downloadFile(sftpClient *sftp.Client, containerPath, hostPath string, info os.FileInfo) error {
+
+	source, err := sftpClient.Open(containerPath)
+	if err != nil {
+		return exit.Errorf(exit.CONNECTION_ERROR, "Failed to open %q inside the container due to error: %v", containerPath, err)
+	}
+	defer source.Close()
+
+	destination, err := os.OpenFile(hostPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, info.Mode().Perm())
+	if err != nil {
+		return exit.Errorf(exit.FILE_ERROR, "Failed to create file %q due to error: %v", hostPath, err)
+	}
+	defer destination.Close()
+
+	return copyWithProgress(destination, source, containerPath, info.Size())
+}
+
+//Copies a single file from the host to a container.
+func uploadFile(sftpClient *sftp.Client, hostPath, containerPath string, info os.FileInfo) error {
+
+	source, err := os.Open(hostPath)
+	if err != nil {
+		return exit.Errorf(exit.FILE_ERROR, "Failed to open file %q due to error: %v", hostPath, err)
+	}
+	defer source.Close()
+
+	destination, err := sftpClient.Create(containerPath)
+	if err != nil {
+		return exit.Errorf(exit.CONNECTION_ERROR, "Failed to create %q inside the container due to error: %v", containerPath, err)
+	}
+	defer destination.Close()
+
+	if err := copyWithProgress(destination, source, hostPath, info.Size()); err != nil {
+		return err
+	}
+
+	if err := sftpClient.Chmod(containerPath, info.Mode().Perm()); err != nil {
+		logger.Debug("Failed to set permissions of %q inside the container: %v", containerPath, err)
+	}
+	return nil
+}
+
+//Copies the content of a file reporting the progress to the user.
+func copyWithProgress(destination io.Writer, source io.Reader, name string, size int64) error {
+
+	progress := utils.NewProgressWriter(destination, name, size)
+	if _, err := io.Copy(progress, source); err != nil {
+		return exit.Errorf(exit.CONNECTION_ERROR, "Failed to copy %q due to error: %v", name, err)
+	}
+	progress.Finish()
+	return nil
+}
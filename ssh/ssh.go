@@ -2,9 +2,18 @@ package ssh
 
 import (
 	"code.google.com/p/go.crypto/ssh"
+	"github.com/SnowRipple/crane/cancellation"
+	"github.com/SnowRipple/crane/constants"
+	"github.com/SnowRipple/crane/dryrun"
+	"github.com/SnowRipple/crane/exit"
 	log "github.com/SnowRipple/crane/logger"
+	"github.com/SnowRipple/crane/output"
+	"github.com/SnowRipple/crane/secret"
+	"github.com/SnowRipple/crane/terminal"
 	"io"
+	"net"
 	"os"
+	"time"
 )
 
 var logger = log.GetLogger()
@@ -77,16 +86,183 @@ const (
 
 const SSH_PORT = ":22"
 
-func SshConnect(sshAddress, username, passwordString, sshCommand string) {
+//Runs a single command inside a container over ssh.
+//Non-zero exit status of the command is returned as an error carrying the same exit code.
+func SshConnect(sshAddress, username, passwordString, sshCommand string) error {
+	return SshConnectWithTimeout(sshAddress, username, passwordString, sshCommand, 0, false, true)
+}
+
+//Runs a single command inside a container over ssh.The command is terminated when it does not finish within the timeout (0 means no timeout) or crane is cancelled.
+//Windows of graphical commands are forwarded to the local display.
+//Only interactive commands read the local stdin, the others get an empty input so sessions run one after another do not compete for it.
+//Non-zero exit status of the command is returned as an error carrying the same exit code.
+func SshConnectWithTimeout(sshAddress, username, passwordString, sshCommand string, timeout time.Duration, graphical, interactive bool) error {
+
+	logger.Debug("Trying to set up ssh connection with SSHAddress:" + sshAddress + ",Username:" + username + ",SSH command:" + sshCommand + ".")
+
+	if dryrun.Enabled() {
+		dryrun.Print("ssh %s%s@%s %s", x11Flag(graphical), username, sshAddress, dryrun.Quote([]string{sshCommand}))
+		return nil
+	}
+
+	// Each ClientConn can support multiple interactive sessions,
+	// represented by a Session, so the connection is shared through the pool.
+	session, err := defaultPool.NewSession(sshAddress, username, passwordString)
+	if err != nil {
+		return err
+	}
+	defer session.Close()
+
+	if graphical {
+		if err := forwardX11(sshAddress, username, passwordString, session); err != nil {
+			return err
+		}
+	}
 
-	logger.Debug("Trying to set up ssh connection with SSHAddress:" + sshAddress + ",Username:" + username + ",password:" + passwordString + ",SSH command:" + sshCommand + ".")
+	width, height := terminal.GetTerminalSize()
+	if err := requestPty(session, width, height, false); err != nil { //The local terminal is not raw, it echoes typed input itself
+		return err
+	}
+
+	if interactive {
+		stdin, err := session.StdinPipe()
+		if err != nil {
+			return exit.Errorf(exit.CONNECTION_ERROR, "Unable to setup stdin for session: %v", err)
+		}
+		go io.Copy(stdin, os.Stdin)
+	}
+
+	stdout, err := session.StdoutPipe()
+	if err != nil {
+		return exit.Errorf(exit.CONNECTION_ERROR, "Unable to setup stdout for session: %v", err)
+	}
+
+	stderr, err := session.StderrPipe()
+	if err != nil {
+		return exit.Errorf(exit.CONNECTION_ERROR, "Unable to setup stderr for session: %v", err)
+	}
+
+	go io.Copy(output.Stdout(), stdout)
+	go io.Copy(os.Stderr, stderr)
+
+	logger.Debug("The following command that will be executed during this SSH session:" + sshCommand)
+	if err := session.Start(sshCommand); err != nil {
+		return exit.Errorf(exit.CONNECTION_ERROR, "SSH Error:Failed to run the command: %v", err)
+	}
+	return waitSession(session, timeout)
+}
+
+//Presents the user with an interactive shell inside a container over ssh.
+//The local terminal is put into raw mode for the duration of the session and its resizes are forwarded to the container.
+//Windows of graphical tools started from the shell are forwarded to the local display.
+//Non-zero exit status of the shell is returned as an error carrying the same exit code.
+func SshEnter(sshAddress, username, passwordString string, graphical bool) error {
+
+	logger.Debug("Trying to set up interactive ssh session with SSHAddress:" + sshAddress + ",Username:" + username + ".")
+
+	if dryrun.Enabled() {
+		dryrun.Print("ssh -t %s%s@%s", x11Flag(graphical), username, sshAddress)
+		return nil
+	}
+
+	session, err := defaultPool.NewSession(sshAddress, username, passwordString)
+	if err != nil {
+		return err
+	}
+	defer session.Close()
+
+	if graphical {
+		if err := forwardX11(sshAddress, username, passwordString, session); err != nil {
+			return err
+		}
+	}
+
+	oldState, err := terminal.MakeTerminalRaw()
+	if err != nil {
+		return err
+	}
+	defer terminal.RestoreTerminal(oldState)
+
+	width, height := terminal.GetTerminalSize()
+	if err := requestPty(session, width, height, oldState != nil); err != nil {
+		return err
+	}
+
+	session.Stdin = os.Stdin
+	session.Stdout = os.Stdout
+	session.Stderr = os.Stderr
+
+	stopWatching := terminal.WatchTerminalResize(func(width, height int) {
+		if err := session.WindowChange(height, width); err != nil {
+			logger.Debug("Failed to forward the terminal resize to the container: %v", err)
+		}
+	})
+	defer stopWatching()
+
+	if err := session.Shell(); err != nil {
+		return exit.Errorf(exit.CONNECTION_ERROR, "SSH Error:Failed to start the shell: %v", err)
+	}
+
+	return waitSession(session, 0)
+}
+
+//Waits for the remote command of a session to finish.
+//When crane is cancelled or the timeout (0 means no timeout) expires the remote command is terminated and the reason is returned.
+func waitSession(session *ssh.Session, timeout time.Duration) error {
+
+	finished := make(chan error, 1)
+	go func() { finished <- session.Wait() }()
+
+	cancelled, stop := cancellation.WithTimeout(timeout)
+
+	select {
+	case err := <-finished:
+		stop()
+		return sessionError(err)
+	case <-cancelled:
+	}
+
+	reason := stop()
+	logger.Debug("Terminating the ssh session: %v", reason)
+
+	//Not every sshd supports signals so the session is closed as well
+	if err := session.Signal(ssh.SIGTERM); err != nil {
+		logger.Debug("Failed to send SIGTERM to the remote command: %v", err)
+	}
+	session.Close()
+	<-finished
+
+	return reason
+}
+
+//Converts the error of a finished ssh session into an error carrying the exit status of the remote command.
+func sessionError(err error) error {
+
+	if err == nil {
+		return nil
+	}
+
+	if exitError, ok := err.(*ssh.ExitError); ok {
+		return exit.Errorf(exitError.ExitStatus(), "Command exited with status %d", exitError.ExitStatus())
+	}
+	return exit.Errorf(exit.CONNECTION_ERROR, "SSH Error:Session failed: %v", err)
+}
+
+//Connects to the ssh server running inside a container.
+//The password may be a secret reference ("secret:<name>", "env:<VARIABLE>", "file:<path>") which is resolved here.
+func dial(sshAddress, username, passwordString string) (*ssh.ClientConn, error) {
 
 	// An SSH client is represented with a slete. Currently only
 	// the "password" authentication method is supported.
 	// To authenticate with the remote server you must pass at least one
 	// implementation of ClientAuth via the Auth field in ClientConfig.
 
-	password := clientPassword(passwordString)
+	resolvedPassword, err := secret.Resolve(passwordString)
+	if err != nil {
+		return nil, err
+	}
+
+	password := clientPassword(resolvedPassword)
 	config := &ssh.ClientConfig{
 		User: username,
 		Auth: []ssh.ClientAuth{
@@ -95,52 +271,54 @@ func SshConnect(sshAddress, username, passwordString, sshCommand string) {
 			ssh.ClientAuthPassword(password),
 		},
 	}
-	client, err := ssh.Dial("tcp", sshAddress+SSH_PORT, config)
+	client, err := ssh.Dial("tcp", dialAddress(sshAddress), config)
 	if err != nil {
-		logger.Fatal("Failed to dial: " + err.Error())
+		return nil, exit.Errorf(exit.CONNECTION_ERROR, "Failed to dial: %v", err)
+	}
+	return client, nil
+}
+
+//Appends the default ssh port unless the address already carries one (e.g. a published port "build.example.com:49153").
+func dialAddress(sshAddress string) string {
+
+	if _, _, err := net.SplitHostPort(sshAddress); err == nil {
+		return sshAddress
 	}
+	return sshAddress + SSH_PORT
+}
+
+//Creates a new session on an existing ssh connection.
+func newSession(client *ssh.ClientConn) (*ssh.Session, error) {
 
-	// Each ClientConn can support multiple interactive sessions,
-	// represented by a Session.
-	defer client.Close()
-	// Create a session
 	session, err := client.NewSession()
 	if err != nil {
-		logger.Fatal("unable to create session: %s", err)
-	}
-	defer session.Close()
-	// Set up terminal modes
-	modes := ssh.TerminalModes{
-		ECHO:          0,     // disable echoing
-		TTY_OP_ISPEED: 14400, // input speed = 14.4kbaud
-		TTY_OP_OSPEED: 14400, // output speed = 14.4kbaud
-	}
-	// Request pseudo terminal
-	if err := session.RequestPty("xterm", 80, 40, modes); err != nil {
-		logger.Fatal("request for pseudo terminal failed: %s", err)
+		return nil, exit.Errorf(exit.CONNECTION_ERROR, "unable to create session: %v", err)
 	}
+	return session, nil
+}
 
-	stdin, err := session.StdinPipe()
-	if err != nil {
-		logger.Fatal("Unable to setup stdin for session: %v\n", err)
-	}
+//Requests a pseudo terminal of the same type and size as the local one.
+//The remote side echoes typed input only when the local terminal is raw, otherwise the input would be shown twice.
+func requestPty(session *ssh.Session, width, height int, rawTerminal bool) error {
 
-	stdout, err := session.StdoutPipe()
-	if err != nil {
-		logger.Fatal("Unable to setup stdout for session: %v\n", err)
+	echo := uint32(0)
+	if rawTerminal {
+		echo = 1
 	}
 
-	stderr, err := session.StdoutPipe()
-	if err != nil {
-		logger.Fatal("Unable to setup stdout for session: %v\n", err)
+	// Set up terminal modes
+	modes := ssh.TerminalModes{
+		ECHO:          echo,
+		TTY_OP_ISPEED: constants.TERMINAL_SPEED, // input speed = 14.4kbaud
+		TTY_OP_OSPEED: constants.TERMINAL_SPEED, // output speed = 14.4kbaud
 	}
 
-	go io.Copy(os.Stdout, stdout)
-	go io.Copy(stdin, os.Stdin)
-	go io.Copy(os.Stderr, stderr)
+	terminalType := terminal.GetTerminalType()
+	logger.Debug("Requesting %q pseudo terminal of size %dx%d", terminalType, width, height)
 
-	logger.Debug("The following command that will be executed during this SSH session:" + sshCommand)
-	if err := session.Run(sshCommand); err != nil {
-		logger.Fatal("SSH Error:Failed to run: " + err.Error())
+	// Request pseudo terminal
+	if err := session.RequestPty(terminalType, height, width, modes); err != nil {
+		return exit.Errorf(exit.CONNECTION_ERROR, "request for pseudo terminal failed: %v", err)
 	}
+	return nil
 }
@@ -0,0 +1,139 @@
+package ssh
+
+import (
+	"code.google.com/p/go.crypto/ssh"
+	"github.com/SnowRipple/crane/exit"
+	"github.com/SnowRipple/crane/x11"
+	"io"
+	"sync"
+)
+
+/*
+ SSH X11 forwarding (RFC 4254, section 6.3)
+*/
+const (
+	X11_REQUEST = "x11-req"
+	X11_CHANNEL = "x11"
+)
+
+//Payload of the x11-req request.
+type x11Request struct {
+	SingleConnection bool
+	AuthProtocol     string
+	AuthCookie       string
+	ScreenNumber     uint32
+}
+
+//Connections already proxying X11 channels to the local display (a channel type can be handled only once per connection).
+//A connection is forgotten once it is closed.
+var (
+	x11ConnectionsMutex sync.Mutex
+	x11Connections      = map[*ssh.ClientConn]bool{}
+)
+
+//Enables X11 forwarding for a session on the pooled connection to a container.
+func forwardX11(sshAddress, username, passwordString string, session *ssh.Session) error {
+
+	client, err := defaultPool.Get(sshAddress, username, passwordString)
+	if err != nil {
+		return err
+	}
+	return requestX11Forwarding(client, session)
+}
+
+//Flag of the equivalent ssh command shown in the dry run mode.
+func x11Flag(graphical bool) string {
+
+	if graphical {
+		return "-X "
+	}
+	return ""
+}
+
+//Asks the sshd inside a container to forward X11 connections of a session and proxies them to the local display.
+func requestX11Forwarding(client *ssh.ClientConn, session *ssh.Session) error {
+
+	display, err := x11.Display()
+	if err != nil {
+		return err
+	}
+	screen, err := x11.Screen(display)
+	if err != nil {
+		return err
+	}
+
+	proxyX11Channels(client, display)
+
+	protocol, cookie := x11.Cookie(display)
+	request := x11Request{AuthProtocol: protocol, AuthCookie: cookie, ScreenNumber: uint32(screen)}
+
+	accepted, err := session.SendRequest(X11_REQUEST, true, ssh.Marshal(&request))
+	if err != nil {
+		return exit.Errorf(exit.CONNECTION_ERROR, "SSH Error:X11 forwarding request failed: %v", err)
+	}
+	if !accepted {
+		return exit.Errorf(exit.CONNECTION_ERROR, "SSH Error:The container refused X11 forwarding.Please make sure its sshd_config has \"X11Forwarding yes\" and xauth is installed in the image.")
+	}
+
+	logger.Debug("X11 forwarding to display %q enabled", display)
+	return nil
+}
+
+//Proxies X11 channels opened by the container to the local display.
+func proxyX11Channels(client *ssh.ClientConn, display string) {
+
+	x11ConnectionsMutex.Lock()
+	defer x11ConnectionsMutex.Unlock()
+
+	if x11Connections[client] {
+		return
+	}
+	x11Connections[client] = true
+
+	channels := client.HandleChannelOpen(X11_CHANNEL)
+
+	go func() {
+		for newChannel := range channels { //Ends when the connection is closed
+			go proxyX11Channel(newChannel, display)
+		}
+
+		x11ConnectionsMutex.Lock()
+		delete(x11Connections, client)
+		x11ConnectionsMutex.Unlock()
+	}()
+}
+
+//Copies data between a single X11 channel and the local X server until either side closes.
+func proxyX11Channel(newChannel ssh.NewChannel, display string) {
+
+	localConnection, err := x11.DialDisplay(display)
+	if err != nil {
+		logger.Error("Failed to connect to display %q: %v", display, err)
+		newChannel.Reject(ssh.ConnectionFailed, err.Error())
+		return
+	}
+
+	channel, requests, err := newChannel.Accept()
+	if err != nil {
+		logger.Debug("Failed to accept X11 channel: %v", err)
+		localConnection.Close()
+		return
+	}
+	go ssh.DiscardRequests(requests)
+
+	finished := make(chan struct{}, 2)
+	go func() {
+		io.Copy(channel, localConnection)
+		finished <- struct{}{}
+	}()
+	go func() {
+		io.Copy(localConnection, channel)
+		finished <- struct{}{}
+	}()
+
+	//Either side closing ends the window's connection
+	<-finished
+	channel.Close()
+	localConnection.Close()
+	<-finished
+}
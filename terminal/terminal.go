@@ -0,0 +1,111 @@
+package terminal
+
+import (
+	sshTerminal "code.google.com/p/go.crypto/ssh/terminal"
+	"fmt"
+	"github.com/SnowRipple/crane/constants"
+	"github.com/SnowRipple/crane/exit"
+	log "github.com/SnowRipple/crane/logger"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+var logger = log.GetLogger()
+
+//Checks if crane's standard input is attached to a terminal.
+func IsTerminal() bool {
+	return sshTerminal.IsTerminal(int(os.Stdin.Fd()))
+}
+
+//Puts the local terminal into raw mode so every keystroke (including Ctrl-C, arrows etc.) is passed to the container unchanged.
+//Returns the previous state of the terminal which has to be restored with RestoreTerminal afterwards.
+//If crane is not attached to a terminal nothing happens and nil is returned.
+func MakeTerminalRaw() (*sshTerminal.State, error) {
+
+	if !IsTerminal() {
+		logger.Debug("Standard input is not a terminal, raw mode will not be used.")
+		return nil, nil
+	}
+
+	oldState, err := sshTerminal.MakeRaw(int(os.Stdin.Fd()))
+	if err != nil {
+		return nil, exit.Errorf(exit.FAILURE, "Failed to put the terminal into raw mode due to error: %v", err)
+	}
+
+	return oldState, nil
+}
+
+//Restores the terminal state saved by MakeTerminalRaw.
+func RestoreTerminal(oldState *sshTerminal.State) {
+
+	if oldState == nil {
+		return
+	}
+
+	if err := sshTerminal.Restore(int(os.Stdin.Fd()), oldState); err != nil {
+		logger.Error("Failed to restore the terminal due to error: %v", err)
+	}
+}
+
+//Asks the user for input without echoing it (passwords etc.).
+func ReadHiddenInput(prompt string) (string, error) {
+
+	fmt.Fprint(os.Stderr, prompt)
+	input, err := sshTerminal.ReadPassword(int(os.Stdin.Fd()))
+	fmt.Fprintln(os.Stderr)
+
+	if err != nil {
+		return "", exit.Errorf(exit.USAGE_ERROR, "Failed to read the input due to error: %v", err)
+	}
+	return string(input), nil
+}
+
+//Returns the type of the local terminal (TERM environment variable) so the container can use the same one.
+func GetTerminalType() string {
+
+	terminalType := os.Getenv("TERM")
+	if len(terminalType) == 0 {
+		return constants.DEFAULT_TERMINAL
+	}
+	return terminalType
+}
+
+//Returns the current size of the local terminal window.If it can't be obtained the default size is returned.
+func GetTerminalSize() (width, height int) {
+
+	width, height, err := sshTerminal.GetSize(int(os.Stdout.Fd()))
+	if err != nil {
+		logger.Debug("Failed to obtain the terminal size, using default one: %v", err)
+		return constants.DEFAULT_TERMINAL_WIDTH, constants.DEFAULT_TERMINAL_HEIGHT
+	}
+	return width, height
+}
+
+//Calls onResize with the new terminal size every time the local terminal window is resized (SIGWINCH).
+//Returns a function that stops watching for resizes.
+func WatchTerminalResize(onResize func(width, height int)) (stop func()) {
+
+	resized := make(chan os.Signal, 1)
+	done := make(chan bool)
+
+	signal.Notify(resized, syscall.SIGWINCH)
+
+	go func() {
+		for {
+			select {
+			case <-resized:
+				width, height := GetTerminalSize()
+				logger.Debug("Terminal resized to %dx%d", width, height)
+				onResize(width, height)
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return func() {
+		signal.Stop(resized)
+		close(done)
+	}
+}
@@ -3,39 +3,59 @@ package utils
 import (
 	"github.com/SnowRipple/crane/config"
 	"github.com/SnowRipple/crane/container"
+	"github.com/SnowRipple/crane/exit"
 )
 
 //Returns requested container config.
-func GetRequestedContainerConfig(containers map[string]container.Container, containerName string, throwError bool) container.Container {
+func GetRequestedContainerConfig(containers map[string]container.Container, containerName string, throwError bool) (container.Container, error) {
 
 	requestedContainer, exists := containers[containerName]
 
 	if throwError && !exists {
-		logger.Fatalf("Chosen container:%q does not exist in the configuration file.Please correct.", containerName)
+		return requestedContainer, exit.Errorf(exit.CONFIG_ERROR, "Chosen container:%q does not exist in the configuration file.Please correct.", containerName)
 	}
 
-	return requestedContainer
+	return requestedContainer, nil
 
 }
 
 //Returns requested container state.
-func GetRequestedContainerState(containers map[string]container.StateContainer, containerName string, throwError bool) container.StateContainer {
+func GetRequestedContainerState(containers map[string]container.StateContainer, containerName string, throwError bool) (container.StateContainer, error) {
 	requestedContainer, exists := containers[containerName]
 	if throwError {
 		if !exists {
-			logger.Fatalf("Chosen container:%q does not exist in the state file.Please correct.", containerName)
+			return requestedContainer, exit.Errorf(exit.CONFIG_ERROR, "Chosen container:%q does not exist in the state file.Please correct.", containerName)
 		} else if requestedContainer.ID == "" {
-			logger.Fatalf("Chosen container:%q has empty ID.", containerName)
+			return requestedContainer, exit.Errorf(exit.CONFIG_ERROR, "Chosen container:%q has empty ID.", containerName)
 		} else if requestedContainer.IP == "" {
-			logger.Fatalf("Chosen container:%q has empty IP.", containerName)
+			return requestedContainer, exit.Errorf(exit.CONFIG_ERROR, "Chosen container:%q has empty IP.", containerName)
 		}
 	}
-	return requestedContainer
+	return requestedContainer, nil
+}
+
+//Returns the docker host of a given name.Empty name means the local daemon.
+func GetRequestedHost(hosts map[string]container.Host, hostName string) (container.Host, error) {
+
+	if len(hostName) == 0 {
+		return container.Host{}, nil
+	}
+
+	host, exists := hosts[hostName]
+	if !exists {
+		return host, exit.Errorf(exit.CONFIG_ERROR, "Host %q does not exist in the configuration file.Please correct.", hostName)
+	}
+	return host, nil
 }
 
 //Returns requested container config and state.
-func GetContainerConfigAndState(config config.TomlConfig, containerName string, throwErrorConfig, throwErrorState bool) (configContainer container.Container, stateContainer container.StateContainer) {
-	configContainer = GetRequestedContainerConfig(config.CraneConfig.Containers, containerName, throwErrorConfig)
-	stateContainer = GetRequestedContainerState(config.CraneState.StateContainers, containerName, throwErrorState)
-	return configContainer, stateContainer
+func GetContainerConfigAndState(config config.TomlConfig, containerName string, throwErrorConfig, throwErrorState bool) (configContainer container.Container, stateContainer container.StateContainer, err error) {
+
+	configContainer, err = GetRequestedContainerConfig(config.CraneConfig.Containers, containerName, throwErrorConfig)
+	if err != nil {
+		return configContainer, stateContainer, err
+	}
+
+	stateContainer, err = GetRequestedContainerState(config.CraneState.StateContainers, containerName, throwErrorState)
+	return configContainer, stateContainer, err
 }
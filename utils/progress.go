@@ -0,0 +1,54 @@
+package utils
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+//Writer that reports to the user how much of a file has been copied so far.
+type ProgressWriter struct {
+	writer  io.Writer
+	name    string
+	total   int64
+	written int64
+	percent int64
+}
+
+//Wraps a writer so every write is reported as a progress of copying the file with a given name and total size.
+func NewProgressWriter(writer io.Writer, name string, total int64) *ProgressWriter {
+	return &ProgressWriter{writer: writer, name: name, total: total, percent: -1}
+}
+
+func (progress *ProgressWriter) Write(bytes []byte) (int, error) {
+
+	written, err := progress.writer.Write(bytes)
+	progress.written += int64(written)
+	progress.report()
+
+	return written, err
+}
+
+//Prints the final progress line.
+func (progress *ProgressWriter) Finish() {
+
+	progress.percent = -1
+	progress.report()
+	fmt.Fprintln(os.Stderr)
+}
+
+//Prints the current progress, but only when the percentage changed so the terminal is not flooded.
+func (progress *ProgressWriter) report() {
+
+	percent := int64(100)
+	if progress.total > 0 {
+		percent = progress.written * 100 / progress.total
+	}
+
+	if percent == progress.percent {
+		return
+	}
+	progress.percent = percent
+
+	fmt.Fprintf(os.Stderr, "\r%s: %d/%d bytes (%d%%)", progress.name, progress.written, progress.total, percent)
+}
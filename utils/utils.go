@@ -3,10 +3,14 @@ package utils
 import (
 	"fmt"
 	log "github.com/SnowRipple/crane/logger"
+	"strings"
 )
 
 var logger = log.GetLogger()
 
+//Messages of docker and podman reporting that an inspected container, image or volume does not exist.
+var missingObjectMessages = []string{"no such container", "no such image", "no such object", "no such volume", "image not known"}
+
 //Prints output of the command in a form that is easy to read
 func PrintCommandOutput(output []byte) {
 
@@ -29,3 +33,19 @@ func ExtractContainerMessage(message []byte, err error) string {
 
 	return cumulativeMessage
 }
+
+//Checks if a failed docker command failed only because the container, image or volume it refers to does not exist.
+//Other failures (unreachable daemon, broken ssh connection, rejected option) are not a proof of absence.
+func IsMissingObject(message []byte, err error) bool {
+
+	if err == nil {
+		return false
+	}
+	lowercaseMessage := strings.ToLower(string(message))
+	for _, missingObjectMessage := range missingObjectMessages {
+		if strings.Contains(lowercaseMessage, missingObjectMessage) {
+			return true
+		}
+	}
+	return false
+}
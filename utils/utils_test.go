@@ -0,0 +1,31 @@
+package utils
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestIsMissingObject(t *testing.T) {
+
+	exitStatus := errors.New("exit status 1")
+
+	cases := []struct {
+		message  string
+		err      error
+		expected bool
+	}{
+		{"Error: No such container: 12233445", exitStatus, true},
+		{"Error: No such image: example/web:1.0", exitStatus, true},
+		{"Error response from daemon: no such volume", exitStatus, true},
+		{"Error: example/web: image not known", exitStatus, true},
+		{"Cannot connect to the Docker daemon at unix:///var/run/docker.sock. Is the docker daemon running?", exitStatus, false},
+		{"ssh: connect to host build.example.com port 22: Connection refused", exitStatus, false},
+		{"No such container mentioned in a successful output", nil, false},
+	}
+
+	for _, testCase := range cases {
+		if missing := IsMissingObject([]byte(testCase.message), testCase.err); missing != testCase.expected {
+			t.Errorf("IsMissingObject(%q, %v) = %v; expected %v", testCase.message, testCase.err, missing, testCase.expected)
+		}
+	}
+}
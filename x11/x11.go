@@ -0,0 +1,221 @@
+package x11
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"github.com/SnowRipple/crane/dryrun"
+	"github.com/SnowRipple/crane/exit"
+	log "github.com/SnowRipple/crane/logger"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+var logger = log.GetLogger()
+
+/*
+ X11 display settings
+*/
+const (
+	DISPLAY_VARIABLE    = "DISPLAY"
+	XAUTHORITY_VARIABLE = "XAUTHORITY"
+
+	SOCKET_DIRECTORY = "/tmp/.X11-unix"
+	SOCKET_PREFIX    = "X"
+	TCP_BASE_PORT    = 6000
+	UNIX_HOST        = "unix"
+
+	XAUTH_COMMAND    = "xauth"
+	WILDCARD_FAMILY  = "ffff" //Lets the cookie match any hostname, containers have their own
+	MIT_MAGIC_COOKIE = "MIT-MAGIC-COOKIE-1"
+	COOKIE_LENGTH    = 16
+
+	RUNTIME_DIRECTORY_VARIABLE = "XDG_RUNTIME_DIR"
+	XAUTHORITY_FILE            = ".crane.xauth" //Kept in the user's runtime (or home) directory so other users can neither read nor plant it
+	XAUTHORITY_FILE_MODE       = 0600
+	CONTAINER_XAUTHORITY_FILE  = "/tmp/.crane.xauth" //Where containers find the cookie
+)
+
+//Returns the local display GUI tools should open on.
+func Display() (string, error) {
+
+	display := os.Getenv(DISPLAY_VARIABLE)
+	if len(display) == 0 {
+		return "", exit.Errorf(exit.CONFIG_ERROR, "%s is not set hence there is no display to open windows on.", DISPLAY_VARIABLE)
+	}
+	return display, nil
+}
+
+//Splits a display like "host:10.0" or ":0" into its host, display number and screen number.
+func parseDisplay(display string) (host string, number, screen int, err error) {
+
+	separator := strings.LastIndex(display, ":")
+	if separator < 0 {
+		return "", 0, 0, exit.Errorf(exit.CONFIG_ERROR, "Invalid %s %q, expected [host]:<display>[.<screen>]", DISPLAY_VARIABLE, display)
+	}
+	host = display[:separator]
+
+	parts := strings.SplitN(display[separator+1:], ".", 2)
+	if number, err = strconv.Atoi(parts[0]); err != nil {
+		return "", 0, 0, exit.Errorf(exit.CONFIG_ERROR, "Invalid %s %q, expected [host]:<display>[.<screen>]", DISPLAY_VARIABLE, display)
+	}
+	if len(parts) == 2 {
+		if screen, err = strconv.Atoi(parts[1]); err != nil {
+			return "", 0, 0, exit.Errorf(exit.CONFIG_ERROR, "Invalid %s %q, expected [host]:<display>[.<screen>]", DISPLAY_VARIABLE, display)
+		}
+	}
+	return host, number, screen, nil
+}
+
+//Checks if a display is served through the X11 unix socket of this machine.
+func isLocalDisplay(host string) bool {
+	return len(host) == 0 || host == UNIX_HOST
+}
+
+//Connects to the X server of a display.
+func DialDisplay(display string) (net.Conn, error) {
+
+	host, number, _, err := parseDisplay(display)
+	if err != nil {
+		return nil, err
+	}
+
+	if isLocalDisplay(host) {
+		return net.Dial("unix", filepath.Join(SOCKET_DIRECTORY, SOCKET_PREFIX+strconv.Itoa(number)))
+	}
+	return net.Dial("tcp", net.JoinHostPort(host, strconv.Itoa(TCP_BASE_PORT+number)))
+}
+
+//Returns the screen number of a display.
+func Screen(display string) (int, error) {
+
+	_, _, screen, err := parseDisplay(display)
+	return screen, err
+}
+
+//Returns the authorization cookie of a display.If xauth does not know the display a random cookie is returned,
+//it only works with X servers that do not check cookies (e.g. after xhost +local:).
+func Cookie(display string) (protocol, cookie string) {
+
+	outputBytes, err := exec.Command(XAUTH_COMMAND, "list", display).Output()
+	if err == nil {
+		for _, line := range strings.Split(string(outputBytes), "\n") {
+			fields := strings.Fields(line)
+			if len(fields) == 3 {
+				return fields[1], fields[2]
+			}
+		}
+	}
+	logger.Debug("No xauth cookie found for display %q, using a random one", display)
+
+	randomBytes := make([]byte, COOKIE_LENGTH)
+	rand.Read(randomBytes)
+	return MIT_MAGIC_COOKIE, hex.EncodeToString(randomBytes)
+}
+
+//Prepares the local display for a container run on this machine.
+//Returns mountpoints (host path, container path, mode) and environment variables (NAME=value) the container needs.
+func ContainerSetup() (mountpoints [][]string, environment []string, err error) {
+
+	display, err := Display()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	host, _, _, err := parseDisplay(display)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !isLocalDisplay(host) {
+		return nil, nil, exit.Errorf(exit.CONFIG_ERROR, "Display %q is not served by this machine (e.g. ssh -X) so its socket can't be mounted into the container.Please use a daemonized container or a local display.", display)
+	}
+
+	mountpoints = [][]string{{SOCKET_DIRECTORY, SOCKET_DIRECTORY, "rw"}}
+	environment = []string{DISPLAY_VARIABLE + "=" + display}
+
+	xauthorityFile, err := generateXauthority(display)
+	if err != nil {
+		logger.Warning("%v.Windows open only if the X server accepts connections without a cookie (e.g. xhost +local:).", err)
+		return mountpoints, environment, nil
+	}
+
+	mountpoints = append(mountpoints, []string{xauthorityFile, CONTAINER_XAUTHORITY_FILE, "ro"})
+	environment = append(environment, XAUTHORITY_VARIABLE+"="+CONTAINER_XAUTHORITY_FILE)
+	return mountpoints, environment, nil
+}
+
+//Returns the path of the user's own Xauthority file for containers: in the runtime directory when there is one, in the home directory otherwise.
+func xauthorityPath() (string, error) {
+
+	if runtimeDirectory := os.Getenv(RUNTIME_DIRECTORY_VARIABLE); len(runtimeDirectory) > 0 {
+		return filepath.Join(runtimeDirectory, XAUTHORITY_FILE), nil
+	}
+	homeDirectory, err := os.UserHomeDir()
+	if err != nil {
+		return "", exit.Errorf(exit.FILE_ERROR, "Failed to find a directory for the Xauthority file: %v", err)
+	}
+	return filepath.Join(homeDirectory, XAUTHORITY_FILE), nil
+}
+
+//Creates the Xauthority file readable by the user only.A file left with wider permissions is restricted again.
+func createXauthority(path string) error {
+
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE, XAUTHORITY_FILE_MODE)
+	if err != nil {
+		return exit.Errorf(exit.FILE_ERROR, "Failed to create %q: %v", path, err)
+	}
+	defer file.Close()
+
+	if err := file.Chmod(XAUTHORITY_FILE_MODE); err != nil {
+		return exit.Errorf(exit.FILE_ERROR, "Failed to restrict permissions of %q: %v", path, err)
+	}
+	return nil
+}
+
+//Writes the display's cookie into the Xauthority file mounted into containers and returns its path.
+//The cookie's hostname is replaced with a wildcard as containers have hostnames of their own.
+func generateXauthority(display string) (string, error) {
+
+	xauthorityFile, err := xauthorityPath()
+	if err != nil {
+		return "", err
+	}
+
+	listCommand := []string{XAUTH_COMMAND, "nlist", display}
+	mergeCommand := []string{XAUTH_COMMAND, "-f", xauthorityFile, "nmerge", "-"}
+
+	if dryrun.Enabled() {
+		dryrun.Print("%s | sed -e 's/^..../%s/' | %s", dryrun.Quote(listCommand), WILDCARD_FAMILY, dryrun.Quote(mergeCommand))
+		return xauthorityFile, nil
+	}
+
+	outputBytes, err := exec.Command(listCommand[0], listCommand[1:]...).Output()
+	if err != nil {
+		return "", exit.Errorf(exit.CONFIG_ERROR, "Failed to read the cookie of display %q with %s: %v", display, XAUTH_COMMAND, err)
+	}
+
+	var cookies []string
+	for _, line := range strings.Split(strings.TrimSpace(string(outputBytes)), "\n") {
+		if len(line) > len(WILDCARD_FAMILY) {
+			cookies = append(cookies, WILDCARD_FAMILY+line[len(WILDCARD_FAMILY):])
+		}
+	}
+	if len(cookies) == 0 {
+		return "", exit.Errorf(exit.CONFIG_ERROR, "%s has no cookie for display %q", XAUTH_COMMAND, display)
+	}
+
+	if err := createXauthority(xauthorityFile); err != nil {
+		return "", err
+	}
+
+	merge := exec.Command(mergeCommand[0], mergeCommand[1:]...)
+	merge.Stdin = strings.NewReader(strings.Join(cookies, "\n") + "\n")
+	if outputBytes, err := merge.CombinedOutput(); err != nil {
+		return "", exit.Errorf(exit.CONFIG_ERROR, "Failed to write %q: %v %s", xauthorityFile, err, strings.TrimSpace(string(outputBytes)))
+	}
+	logger.Debug("Wrote the cookie of display %q into %q", display, xauthorityFile)
+	return xauthorityFile, nil
+}
@@ -0,0 +1,62 @@
+package x11
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseDisplay(t *testing.T) {
+
+	cases := []struct {
+		display        string
+		host           string
+		number, screen int
+	}{
+		{":0", "", 0, 0},
+		{"unix:1", "unix", 1, 0},
+		{"localhost:10.2", "localhost", 10, 2},
+	}
+
+	for _, testCase := range cases {
+		host, number, screen, err := parseDisplay(testCase.display)
+		if err != nil {
+			t.Fatalf("Unexpected error for %q: %v", testCase.display, err)
+		}
+		if host != testCase.host || number != testCase.number || screen != testCase.screen {
+			t.Errorf("parseDisplay(%q) = %q, %d, %d; expected %q, %d, %d", testCase.display, host, number, screen, testCase.host, testCase.number, testCase.screen)
+		}
+	}
+
+	if _, _, _, err := parseDisplay("nodisplay"); err == nil {
+		t.Errorf("Expected an error for a display without a number")
+	}
+}
+
+func TestCreateXauthority(t *testing.T) {
+
+	runtimeDirectory := t.TempDir()
+	t.Setenv(RUNTIME_DIRECTORY_VARIABLE, runtimeDirectory)
+
+	path, err := xauthorityPath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if path != filepath.Join(runtimeDirectory, XAUTHORITY_FILE) {
+		t.Errorf("Xauthority file is %q; expected it in %q", path, runtimeDirectory)
+	}
+
+	if err := os.WriteFile(path, nil, 0644); err != nil { //Left behind with wider permissions
+		t.Fatal(err)
+	}
+	if err := createXauthority(path); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != XAUTHORITY_FILE_MODE {
+		t.Errorf("Xauthority file permissions are %v; expected %v", info.Mode().Perm(), os.FileMode(XAUTHORITY_FILE_MODE))
+	}
+}